@@ -31,6 +31,17 @@ type configurations struct {
 	S3BucketURL    string `json:"s3_bucket_url" koanf:"S3_BUCKET_URL"`
 	AWSAccessKey   string `json:"aws_access_key" koanf:"AWS_ACCESS_KEY_ID"`
 	AWSSecretKey   string `json:"aws_secret_key" koanf:"AWS_SECRET_ACCESS_KEY"`
+
+	StorageBackend   string `json:"storage_backend" koanf:"STORAGE_BACKEND" valid:"in(s3|local)"`
+	LocalStorageDir  string `json:"local_storage_dir" koanf:"LOCAL_STORAGE_DIR"`
+	LocalStorageURL  string `json:"local_storage_url" koanf:"LOCAL_STORAGE_URL"`
+	PublicStorageURL string `json:"public_storage_url" koanf:"PUBLIC_STORAGE_URL"`
+
+	OrphanedImagesGracePeriodHours string `json:"orphaned_images_grace_period_hours" koanf:"ORPHANED_IMAGES_GRACE_PERIOD_HOURS"`
+
+	TrendingHalfLifeDays string `json:"trending_half_life_days" koanf:"TRENDING_HALF_LIFE_DAYS"`
+
+	TrashRetentionDays string `json:"trash_retention_days" koanf:"TRASH_RETENTION_DAYS"`
 }
 
 var (
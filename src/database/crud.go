@@ -7,6 +7,10 @@ import (
 	"time"
 )
 
+// JobTimeout limits the queries of the scheduled jobs and the maintenance commands, which go over whole tables and
+// do not fit in the timeout of the request queries
+const JobTimeout = 10 * time.Minute
+
 // Ping makes a simple ping with 3 second timeout
 func Ping() error {
 	var ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
@@ -25,6 +29,12 @@ func ExecuteQuery(query string) (sql.Result, error) {
 func ExecuteNamedQuery(query string, arg interface{}) (sql.Result, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
+	return ExecuteNamedQueryContext(ctx, query, arg)
+}
+
+// ExecuteNamedQueryContext executes queries such as INSERT, UPDATE or DELETE with named parameters, limited by the
+// given context instead of the default timeout
+func ExecuteNamedQueryContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
 	return instance.DB.Unsafe().NamedExecContext(ctx, query, arg)
 }
 
@@ -51,14 +61,26 @@ func GetMultipleRecords(destination interface{}, query string) (err error) {
 	//err = backoff.Retry(func() error {
 	var ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
-	return instance.DB.Unsafe().SelectContext(ctx, destination, query)
+	return GetMultipleRecordsContext(ctx, destination, query)
 	//}, utils2.RetryConfig())
 }
 
+// GetMultipleRecordsContext selects multiple records from the database, limited by the given context instead of the
+// default timeout
+func GetMultipleRecordsContext(ctx context.Context, destination interface{}, query string) error {
+	return instance.DB.Unsafe().SelectContext(ctx, destination, query)
+}
+
 // GetMultipleRecordsNamedQuery selects multiple records from the database from a query with named parameters
 func GetMultipleRecordsNamedQuery(destination interface{}, query string, input map[string]interface{}) error {
 	var ctx, cancel = context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
+	return GetMultipleRecordsNamedQueryContext(ctx, destination, query, input)
+}
+
+// GetMultipleRecordsNamedQueryContext selects multiple records from the database from a query with named parameters,
+// limited by the given context instead of the default timeout
+func GetMultipleRecordsNamedQueryContext(ctx context.Context, destination interface{}, query string, input map[string]interface{}) error {
 	parsedQuery, arguments, err := sqlx.Named(query, input)
 	if err != nil {
 		return nil
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS meal_plan_entries
+(
+    id          SERIAL PRIMARY KEY,
+    user_id     INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    recipe_id   INT       NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    planned_for DATE      NOT NULL,
+    meal_slot   TEXT      NOT NULL CHECK (meal_slot IN ('BREAKFAST', 'LUNCH', 'DINNER', 'SNACK')),
+    servings    INT       NOT NULL DEFAULT 1 CHECK (servings > 0),
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS meal_plan_entries_user_id_planned_for_idx ON meal_plan_entries (user_id, planned_for);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS collections
+(
+    id              SERIAL PRIMARY KEY,
+    owner_id        INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name            VARCHAR(255) NOT NULL,
+    description     TEXT,
+    cover_image_url TEXT,
+    is_public       BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS collection_recipes
+(
+    collection_id INT       NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    recipe_id     INT       NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    position      INT       NOT NULL,
+    added_by      INT       REFERENCES users (id) ON DELETE SET NULL,
+    added_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, recipe_id)
+);
+
+CREATE TABLE IF NOT EXISTS collection_collaborators
+(
+    collection_id INT NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    user_id       INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (collection_id, user_id)
+);
@@ -0,0 +1,25 @@
+ALTER TABLE recipes
+    ADD COLUMN IF NOT EXISTS forked_from_id          INT REFERENCES recipes (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS forked_from_recipe_name VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS forked_from_username    VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS recipes_forked_from_id_idx ON recipes (forked_from_id);
+
+-- the recipe status may be kept as an enum, which needs the draft status of the forks as well
+DO
+$$
+    DECLARE
+        status_type TEXT;
+    BEGIN
+        SELECT udt_name
+        INTO status_type
+        FROM information_schema.columns
+        WHERE table_name = 'recipes'
+          AND column_name = 'status'
+          AND data_type = 'USER-DEFINED';
+
+        IF status_type IS NOT NULL THEN
+            EXECUTE FORMAT('ALTER TYPE %I ADD VALUE IF NOT EXISTS ''DRAFT''', status_type);
+        END IF;
+    END
+$$;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS recipe_similarities
+(
+    recipe_id         INT              NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    similar_recipe_id INT              NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    score             DOUBLE PRECISION NOT NULL,
+    computed_at       TIMESTAMP        NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS recipe_similarities_recipe_id_idx ON recipe_similarities (recipe_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_recipe_views
+(
+    user_id   INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    recipe_id INT       NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    viewed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, recipe_id)
+);
+
+CREATE TABLE IF NOT EXISTS hidden_users
+(
+    user_id        INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    hidden_user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, hidden_user_id)
+);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS recipe_daily_views
+(
+    recipe_id INT  NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    day       DATE NOT NULL,
+    views     INT  NOT NULL DEFAULT 0,
+    PRIMARY KEY (recipe_id, day)
+);
+
+CREATE TABLE IF NOT EXISTS recipe_trending_scores
+(
+    recipe_id   INT              NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    score       DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMP        NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS recipe_trending_scores_score_idx ON recipe_trending_scores (score DESC);
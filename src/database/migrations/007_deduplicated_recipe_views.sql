@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS recipe_view_visitors
+(
+    recipe_id      INT          NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    visitor_key    VARCHAR(255) NOT NULL,
+    last_viewed_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (recipe_id, visitor_key)
+);
+
+ALTER TABLE recipe_daily_views
+    ADD COLUMN IF NOT EXISTS synced_views INT NOT NULL DEFAULT 0;
+
+-- views recorded before this migration were already added to recipes.visitations_count
+UPDATE recipe_daily_views SET synced_views = views;
@@ -0,0 +1,3 @@
+ALTER TABLE recipes
+    ADD COLUMN IF NOT EXISTS suspected_duplicate_of_id INT REFERENCES recipes (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS duplicate_score           DOUBLE PRECISION;
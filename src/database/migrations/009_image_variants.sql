@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS image_variants
+(
+    full_url      TEXT PRIMARY KEY,
+    card_url      TEXT      NOT NULL,
+    thumbnail_url TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
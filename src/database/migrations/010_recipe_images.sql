@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS recipe_images
+(
+    id         SERIAL PRIMARY KEY,
+    recipe_id  INT          NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    image_url  TEXT         NOT NULL,
+    alt_text   VARCHAR(255) NOT NULL DEFAULT '',
+    position   INT          NOT NULL,
+    is_cover   BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS recipe_images_recipe_id_position_idx ON recipe_images (recipe_id, position);
+CREATE INDEX IF NOT EXISTS recipe_images_image_url_idx ON recipe_images (image_url);
+
+-- the existing recipe image becomes the cover of the gallery
+INSERT INTO recipe_images (recipe_id, image_url, alt_text, position, is_cover, created_at)
+SELECT id, image_url, '', 1, TRUE, NOW()
+FROM recipes
+WHERE COALESCE(image_url, '') != ''
+  AND NOT EXISTS(SELECT 1 FROM recipe_images WHERE recipe_images.recipe_id = recipes.id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS pending_uploads
+(
+    key          TEXT PRIMARY KEY,
+    user_id      INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind         VARCHAR(32) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    size         BIGINT      NOT NULL,
+    created_at   TIMESTAMP   NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pending_uploads_expires_at_idx ON pending_uploads (expires_at);
@@ -0,0 +1,8 @@
+-- The image columns keep object keys instead of absolute URLs. The existing rows are rewritten by running the
+-- server with the migrate-image-keys command.
+ALTER TABLE image_variants
+    RENAME COLUMN full_url TO full_key;
+ALTER TABLE image_variants
+    RENAME COLUMN card_url TO card_key;
+ALTER TABLE image_variants
+    RENAME COLUMN thumbnail_url TO thumbnail_key;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS failed_image_deletions
+(
+    key        TEXT PRIMARY KEY,
+    attempts   INT       NOT NULL DEFAULT 1,
+    last_error TEXT      NOT NULL,
+    failed_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
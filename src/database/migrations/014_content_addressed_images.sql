@@ -0,0 +1,5 @@
+ALTER TABLE image_variants
+    ADD COLUMN IF NOT EXISTS content_hash     CHAR(64),
+    ADD COLUMN IF NOT EXISTS last_uploaded_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE UNIQUE INDEX IF NOT EXISTS image_variants_content_hash_idx ON image_variants (content_hash);
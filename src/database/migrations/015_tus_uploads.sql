@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS tus_uploads
+(
+    id            TEXT PRIMARY KEY,
+    key           TEXT        NOT NULL,
+    user_id       INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind          VARCHAR(32) NOT NULL,
+    length        BIGINT      NOT NULL,
+    upload_offset BIGINT      NOT NULL DEFAULT 0,
+    chunk_keys    TEXT[]      NOT NULL DEFAULT '{}',
+    created_at    TIMESTAMP   NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS tus_uploads_expires_at_idx ON tus_uploads (expires_at);
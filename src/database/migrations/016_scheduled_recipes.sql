@@ -0,0 +1,38 @@
+ALTER TABLE recipes
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+-- a publish_at column created without a time zone keeps its times as UTC
+DO
+$$
+    BEGIN
+        IF EXISTS(SELECT 1
+                  FROM information_schema.columns
+                  WHERE table_name = 'recipes'
+                    AND column_name = 'publish_at'
+                    AND data_type = 'timestamp without time zone') THEN
+            ALTER TABLE recipes
+                ALTER COLUMN publish_at TYPE TIMESTAMPTZ USING publish_at AT TIME ZONE 'UTC';
+        END IF;
+    END
+$$;
+
+CREATE INDEX IF NOT EXISTS recipes_publish_at_idx ON recipes (publish_at);
+
+-- the recipe status may be kept as an enum, which needs the new status as well
+DO
+$$
+    DECLARE
+        status_type TEXT;
+    BEGIN
+        SELECT udt_name
+        INTO status_type
+        FROM information_schema.columns
+        WHERE table_name = 'recipes'
+          AND column_name = 'status'
+          AND data_type = 'USER-DEFINED';
+
+        IF status_type IS NOT NULL THEN
+            EXECUTE FORMAT('ALTER TYPE %I ADD VALUE IF NOT EXISTS ''SCHEDULED''', status_type);
+        END IF;
+    END
+$$;
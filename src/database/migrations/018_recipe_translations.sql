@@ -0,0 +1,16 @@
+ALTER TABLE recipes
+    ADD COLUMN IF NOT EXISTS language VARCHAR(8) NOT NULL DEFAULT 'bg';
+
+CREATE TABLE IF NOT EXISTS recipe_translations
+(
+    recipe_id   INT        NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    language    VARCHAR(8) NOT NULL,
+    recipe_name TEXT       NOT NULL,
+    products    JSONB      NOT NULL,
+    steps       TEXT[]     NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMP  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (recipe_id, language)
+);
+
+CREATE INDEX IF NOT EXISTS recipe_translations_recipe_name_idx ON recipe_translations (recipe_name);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS ingredient_prices
+(
+    id             SERIAL PRIMARY KEY,
+    ingredient     TEXT           NOT NULL UNIQUE,
+    unit           VARCHAR(8)     NOT NULL,
+    price_per_unit NUMERIC(10, 2) NOT NULL,
+    currency       VARCHAR(3)     NOT NULL,
+    updated_at     TIMESTAMP      NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS recipe_cost_estimates
+(
+    recipe_id            INT PRIMARY KEY REFERENCES recipes (id) ON DELETE CASCADE,
+    total_cost           NUMERIC(10, 2) NOT NULL,
+    per_serving_cost     NUMERIC(10, 2) NOT NULL,
+    currency             VARCHAR(3)     NOT NULL DEFAULT '',
+    is_complete          BOOLEAN        NOT NULL,
+    unpriced_ingredients TEXT[]         NOT NULL DEFAULT '{}',
+    computed_at          TIMESTAMP      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS recipe_cost_estimates_total_cost_idx ON recipe_cost_estimates (total_cost);
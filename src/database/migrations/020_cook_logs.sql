@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS cook_logs
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT           NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    recipe_id  INT           NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    cooked_on  DATE          NOT NULL,
+    photo_url  TEXT          NOT NULL DEFAULT '',
+    note       VARCHAR(1000) NOT NULL DEFAULT '',
+    rating     SMALLINT CHECK (rating BETWEEN 1 AND 5),
+    created_at TIMESTAMP     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS cook_logs_recipe_id_cooked_on_idx ON cook_logs (recipe_id, cooked_on);
+CREATE INDEX IF NOT EXISTS cook_logs_photo_url_idx ON cook_logs (photo_url);
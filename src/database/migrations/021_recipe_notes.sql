@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS recipe_notes
+(
+    id               SERIAL PRIMARY KEY,
+    user_id          INT           NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    recipe_id        INT           NOT NULL REFERENCES recipes (id) ON DELETE CASCADE,
+    step_number      INT CHECK (step_number > 0),
+    ingredient_index INT CHECK (ingredient_index >= 0),
+    content          VARCHAR(2000) NOT NULL,
+    created_at       TIMESTAMP     NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP     NOT NULL DEFAULT NOW(),
+    CHECK (step_number IS NULL OR ingredient_index IS NULL)
+);
+
+CREATE INDEX IF NOT EXISTS recipe_notes_user_id_recipe_id_idx ON recipe_notes (user_id, recipe_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS ingredient_substitutions
+(
+    id           SERIAL PRIMARY KEY,
+    ingredient   TEXT          NOT NULL,
+    substitute   TEXT          NOT NULL,
+    ratio        NUMERIC(6, 2) NOT NULL DEFAULT 1 CHECK (ratio > 0),
+    notes        VARCHAR(1000) NOT NULL DEFAULT '',
+    dietary_tags TEXT[]        NOT NULL DEFAULT '{}',
+    updated_at   TIMESTAMP     NOT NULL DEFAULT NOW(),
+    UNIQUE (ingredient, substitute)
+);
@@ -0,0 +1,3 @@
+-- the recipes created before the servings were recorded keep 0, their per serving cost is not estimated
+ALTER TABLE recipes
+    ADD COLUMN IF NOT EXISTS servings INT NOT NULL DEFAULT 0;
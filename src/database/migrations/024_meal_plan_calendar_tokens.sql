@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS meal_plan_calendar_tokens
+(
+    user_id    INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP   NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+-- the images uploaded before the webp variants were added keep empty webp keys
+ALTER TABLE image_variants
+    ADD COLUMN IF NOT EXISTS full_webp_key      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS card_webp_key      TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS thumbnail_webp_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- the recipe images each user uploaded, so a recipe can only use the images of its author or of its own gallery
+CREATE TABLE IF NOT EXISTS recipe_image_uploads
+(
+    image_key   TEXT      NOT NULL,
+    user_id     INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    uploaded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (image_key, user_id)
+);
@@ -51,13 +51,15 @@ func MostActiveUser() (user MostActiveUserData, err error) {
                               avatar_url,
                               COUNT(recipes.id) AS recipes_count
                        FROM users
-                                LEFT JOIN recipes ON recipes.owner_id = users.id
+                                LEFT JOIN recipes ON recipes.owner_id = users.id AND recipes.deleted_at IS NULL
+                       WHERE users.deleted_at IS NULL
                        GROUP BY users.username, avatar_url),
 
 			 users_comments AS (SELECT username,
 									   COUNT(comments.id) AS comments_count
 								FROM users
-										 LEFT JOIN comments ON comments.owner_id = users.id
+										 LEFT JOIN comments ON comments.owner_id = users.id AND comments.deleted_at IS NULL
+								WHERE users.deleted_at IS NULL
 								GROUP BY users.username)
 		
 		SELECT users_recipes.username,
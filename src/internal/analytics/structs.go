@@ -1,5 +1,7 @@
 package analytics
 
+import "recipes-v2-server/storage"
+
 type Data struct {
 	Data []int `json:"data"`
 }
@@ -15,9 +17,9 @@ type VisitationsData struct {
 }
 
 type MostActiveUserData struct {
-	Username               string `json:"username" db:"username"`
-	AvatarURL              string `json:"avatarURL" db:"avatar_url"`
-	RecipesCount           int    `json:"recipesCount" db:"recipes_count"`
-	CommentsCount          int    `json:"commentsCount" db:"comments_count"`
-	TotalPublicationsCount int    `json:"totalPublicationsCount" db:"total_publications_count"`
+	Username               string            `json:"username" db:"username"`
+	AvatarURL              storage.ObjectKey `json:"avatarURL" db:"avatar_url"`
+	RecipesCount           int               `json:"recipesCount" db:"recipes_count"`
+	CommentsCount          int               `json:"commentsCount" db:"comments_count"`
+	TotalPublicationsCount int               `json:"totalPublicationsCount" db:"total_publications_count"`
 }
@@ -34,7 +34,7 @@ func Login(loginData UserAuthData) (userData UserAuthDataResult, err error) {
 				FROM users
 						 JOIN users_roles ON users.id = users_roles.user_entity_id
 						 JOIN roles ON users_roles.roles_id = roles.id
-				WHERE username = :username`,
+				WHERE username = :username AND deleted_at IS NULL`,
 		loginData,
 	)
 	if err != nil {
@@ -181,7 +181,7 @@ func RequestVerificationCode(emailData EmailData) (response VerificationCodeData
 		&response,
 		`WITH user_data AS (SELECT id, email, username, :code AS code
                    FROM users
-                   WHERE email = :email),
+                   WHERE email = :email AND deleted_at IS NULL),
 
 					 insert_data AS (INSERT
 						 INTO password_requests (code, issued_at, issued_by_user, publication_status_enum)
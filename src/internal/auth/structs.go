@@ -1,5 +1,7 @@
 package auth
 
+import "recipes-v2-server/storage"
+
 type UsernameData struct {
 	Username string `db:"username" json:"username" valid:"required,minstringlength(3)"`
 }
@@ -35,14 +37,14 @@ type VerificationCodeData struct {
 }
 
 type UserAuthDataResult struct {
-	Username        string `db:"username" json:"username"`
-	Role            string `db:"role" json:"-"`
-	Id              int    `db:"id" json:"id"`
-	AvatarURL       string `db:"avatar_url" json:"avatarURL"`
-	CoverPhotoURL   string `db:"cover_photo_url" json:"coverPhotoURL"`
-	Email           string `db:"email" json:"email"`
-	IsAdministrator bool   `db:"is_administrator" json:"isAdministrator"`
-	IsModerator     bool   `db:"is_moderator" json:"isModerator"`
-	SessionToken    string `json:"sessionToken"`
-	NewPassword     string `db:"new_password" json:"-"`
+	Username        string            `db:"username" json:"username"`
+	Role            string            `db:"role" json:"-"`
+	Id              int               `db:"id" json:"id"`
+	AvatarURL       storage.ObjectKey `db:"avatar_url" json:"avatarURL"`
+	CoverPhotoURL   storage.ObjectKey `db:"cover_photo_url" json:"coverPhotoURL"`
+	Email           string            `db:"email" json:"email"`
+	IsAdministrator bool              `db:"is_administrator" json:"isAdministrator"`
+	IsModerator     bool              `db:"is_moderator" json:"isModerator"`
+	SessionToken    string            `json:"sessionToken"`
+	NewPassword     string            `db:"new_password" json:"-"`
 }
@@ -0,0 +1,324 @@
+package collections
+
+import (
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/storage"
+	"recipes-v2-server/utils"
+)
+
+// GetForUser gets the collections of the given user. Private collections are included only when the requester is
+// the owner or a collaborator. The favourites list of the user is always returned first as the default collection.
+func GetForUser(username string, requesterId int) (collections []Collection, err error) {
+	favourites := Collection{Name: "Favourites", IsPublic: true, IsDefault: true, OwnerName: username}
+
+	err = database.GetSingleRecordNamedQuery(
+		&favourites.RecipesCount,
+		`SELECT COUNT(recipes.id)
+				FROM users_favourites
+						 JOIN users ON users.id = users_favourites.user_entity_id
+						 JOIN recipes ON recipes.id = users_favourites.favourites_id
+				WHERE username = :username
+				  AND users.deleted_at IS NULL
+				  AND recipes.deleted_at IS NULL;`,
+		map[string]interface{}{"username": username},
+	)
+	if err != nil {
+		return
+	}
+
+	err = database.GetMultipleRecordsNamedQuery(
+		&collections,
+		`SELECT collections.id,
+					   name,
+					   COALESCE(description, '')     AS description,
+					   COALESCE(cover_image_url, '') AS cover_image_url,
+					   is_public,
+					   false                         AS is_default,
+					   username                      AS owner_name,
+					   COUNT(recipes.id)             AS recipes_count
+				FROM collections
+						 JOIN users ON users.id = collections.owner_id
+						 LEFT JOIN collection_recipes ON collection_recipes.collection_id = collections.id
+						 LEFT JOIN recipes ON recipes.id = collection_recipes.recipe_id
+						AND recipes.status = 'APPROVED'
+						AND recipes.deleted_at IS NULL
+				WHERE username = :username
+				  AND users.deleted_at IS NULL
+				  AND (is_public
+					OR owner_id = :requester_id
+					OR EXISTS(SELECT user_id
+							  FROM collection_collaborators
+							  WHERE collection_id = collections.id
+								AND user_id = :requester_id))
+				GROUP BY collections.id, username
+				ORDER BY collections.created_at;`,
+		map[string]interface{}{"username": username, "requester_id": requesterId},
+	)
+
+	collections = append([]Collection{favourites}, collections...)
+	return
+}
+
+// GetById gets the collection with its ordered recipes, if it is visible to the requester
+func GetById(id, requesterId int) (collection CollectionDetails, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&collection,
+		`SELECT collections.id,
+					   name,
+					   COALESCE(description, '')     AS description,
+					   COALESCE(cover_image_url, '') AS cover_image_url,
+					   is_public,
+					   false                         AS is_default,
+					   username                      AS owner_name,
+					   (SELECT COUNT(recipes.id)
+						FROM collection_recipes
+								 JOIN recipes ON recipes.id = collection_recipes.recipe_id
+						WHERE collection_id = collections.id
+						  AND recipes.status = 'APPROVED'
+						  AND recipes.deleted_at IS NULL) AS recipes_count,
+					   ARRAY(SELECT collaborators.username
+							 FROM collection_collaborators
+									  JOIN users AS collaborators ON collaborators.id = collection_collaborators.user_id
+							 WHERE collection_id = collections.id
+							   AND collaborators.deleted_at IS NULL) AS collaborators
+				FROM collections
+						 JOIN users ON users.id = collections.owner_id
+				WHERE collections.id = :id
+				  AND users.deleted_at IS NULL
+				  AND (is_public
+					OR owner_id = :requester_id
+					OR EXISTS(SELECT user_id
+							  FROM collection_collaborators
+							  WHERE collection_id = collections.id
+								AND user_id = :requester_id));`,
+		map[string]interface{}{"id": id, "requester_id": requesterId},
+	)
+	if err != nil {
+		return
+	}
+
+	err = database.GetMultipleRecordsNamedQuery(
+		&collection.Recipes,
+		`SELECT recipe_name,
+					   image_url
+				FROM collection_recipes
+						 JOIN recipes ON recipes.id = collection_recipes.recipe_id
+				WHERE collection_id = :id AND recipes.status = 'APPROVED' AND recipes.deleted_at IS NULL
+				ORDER BY position;`,
+		map[string]interface{}{"id": id},
+	)
+	return
+}
+
+// Create creates a new collection. The cover image is set only through UploadCoverImage.
+func Create(request CollectionRequest) (collection Collection, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&collection,
+		`WITH inserted_collection AS (INSERT INTO collections (owner_id, name, description, is_public, created_at)
+						 VALUES (:owner_id, :name, :description, :is_public, NOW())
+						 RETURNING *)
+
+				SELECT inserted_collection.id,
+					   name,
+					   COALESCE(description, '')     AS description,
+					   COALESCE(cover_image_url, '') AS cover_image_url,
+					   is_public,
+					   false                         AS is_default,
+					   username                      AS owner_name,
+					   0                             AS recipes_count
+				FROM inserted_collection
+						 JOIN users ON users.id = inserted_collection.owner_id;`,
+		request,
+	)
+	return
+}
+
+// Edit edits the collection name, description and visibility
+func Edit(request CollectionRequest) (collection Collection, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&collection,
+		`WITH updated_collection AS (UPDATE collections
+						 SET name        = :name,
+							 description = :description,
+							 is_public   = :is_public
+						 WHERE id = :id
+						 RETURNING *)
+
+				SELECT updated_collection.id,
+					   name,
+					   COALESCE(description, '')     AS description,
+					   COALESCE(cover_image_url, '') AS cover_image_url,
+					   is_public,
+					   false                         AS is_default,
+					   username                      AS owner_name,
+					   (SELECT COUNT(recipes.id)
+						FROM collection_recipes
+								 JOIN recipes ON recipes.id = collection_recipes.recipe_id
+						WHERE collection_id = :id
+						  AND recipes.status = 'APPROVED'
+						  AND recipes.deleted_at IS NULL) AS recipes_count
+				FROM updated_collection
+						 JOIN users ON users.id = updated_collection.owner_id;`,
+		request,
+	)
+	return
+}
+
+// Delete deletes a collection together with its recipe and collaborator references
+func Delete(id int) (err error) {
+	var oldImageKey storage.ObjectKey
+
+	err = database.GetSingleRecordNamedQuery(
+		&oldImageKey,
+		`WITH delete_recipes AS (DELETE FROM collection_recipes WHERE collection_id = :id),
+					 delete_collaborators AS (DELETE FROM collection_collaborators WHERE collection_id = :id)
+
+				DELETE
+				FROM collections
+				WHERE id = :id
+				RETURNING COALESCE(cover_image_url, '');`,
+		map[string]interface{}{"id": id},
+	)
+	if err != nil {
+		return
+	}
+
+	if oldImageKey != "" {
+		err = images.Delete(oldImageKey)
+	}
+	return
+}
+
+// AddRecipe appends an approved recipe at the end of the collection. A recipe already in the collection keeps its
+// position.
+func AddRecipe(id, addedBy int, recipeName string) (err error) {
+	var recipeId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&recipeId,
+		`INSERT INTO collection_recipes (collection_id, recipe_id, position, added_by, added_at)
+				SELECT :id,
+					   recipes.id,
+					   (SELECT COALESCE(MAX(position), 0) + 1 FROM collection_recipes WHERE collection_id = :id),
+					   :added_by,
+					   NOW()
+				FROM recipes
+				WHERE recipe_name = :recipe_name AND status = 'APPROVED' AND deleted_at IS NULL
+				ON CONFLICT (collection_id, recipe_id) DO UPDATE SET recipe_id = EXCLUDED.recipe_id
+				RETURNING recipe_id;`,
+		map[string]interface{}{"id": id, "added_by": addedBy, "recipe_name": recipeName},
+	)
+	return
+}
+
+// RemoveRecipe removes a recipe from the collection
+func RemoveRecipe(id int, recipeName string) (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`DELETE
+				FROM collection_recipes
+				WHERE collection_id = :id
+				  AND recipe_id = (SELECT id FROM recipes WHERE recipe_name = :recipe_name);`,
+		map[string]interface{}{"id": id, "recipe_name": recipeName},
+	)
+	return
+}
+
+// Reorder sets the position of the recipes in the collection to their index in the given recipe names list
+func Reorder(id int, recipeNames []string) (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`UPDATE collection_recipes
+				SET position = ordered_recipes.position
+				FROM (SELECT recipes.id, names.position
+					  FROM UNNEST(CAST(:recipe_names AS TEXT[])) WITH ORDINALITY AS names(recipe_name, position)
+							   JOIN recipes ON recipes.recipe_name = names.recipe_name) AS ordered_recipes
+				WHERE collection_recipes.collection_id = :id
+				  AND collection_recipes.recipe_id = ordered_recipes.id;`,
+		map[string]interface{}{"id": id, "recipe_names": pq.StringArray(recipeNames)},
+	)
+	return
+}
+
+// AddCollaborator allows the user with the given username to add recipes to the collection
+func AddCollaborator(id int, username string) (err error) {
+	var userId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&userId,
+		`INSERT INTO collection_collaborators (collection_id, user_id)
+				SELECT :id, users.id
+				FROM users
+				WHERE username = :username
+				ON CONFLICT (collection_id, user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+				RETURNING user_id;`,
+		map[string]interface{}{"id": id, "username": username},
+	)
+	return
+}
+
+// RemoveCollaborator removes the collaborator access of the user with the given username
+func RemoveCollaborator(id int, username string) (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`DELETE
+				FROM collection_collaborators
+				WHERE collection_id = :id
+				  AND user_id = (SELECT id FROM users WHERE username = :username);`,
+		map[string]interface{}{"id": id, "username": username},
+	)
+	return
+}
+
+// IsOwner checks if the user is the owner of the collection
+func IsOwner(id, userId int) (isOwner bool, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&isOwner,
+		`SELECT EXISTS(SELECT id FROM collections WHERE id = :id AND owner_id = :user_id);`,
+		map[string]interface{}{"id": id, "user_id": userId},
+	)
+	return
+}
+
+// CanAddRecipes checks if the user is the owner or a collaborator of the collection
+func CanAddRecipes(id, userId int) (canAdd bool, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&canAdd,
+		`SELECT EXISTS(SELECT id FROM collections WHERE id = :id AND owner_id = :user_id)
+					OR EXISTS(SELECT user_id FROM collection_collaborators WHERE collection_id = :id AND user_id = :user_id);`,
+		map[string]interface{}{"id": id, "user_id": userId},
+	)
+	return
+}
+
+// UploadCoverImage uploads a new cover image for the collection and removes the variants of the previous one
+func UploadCoverImage(content io.Reader, id int) (variants images.Variants, err error) {
+	variants, err = images.Upload(content, images.CoverImage)
+	if err != nil {
+		return
+	}
+
+	var oldImageKey storage.ObjectKey
+
+	err = database.GetSingleRecordNamedQuery(
+		&oldImageKey,
+		`UPDATE collections
+				SET cover_image_url = :image_url
+				FROM (SELECT id, cover_image_url FROM collections WHERE id = :id) AS previous
+				WHERE collections.id = previous.id
+				RETURNING COALESCE(previous.cover_image_url, '');`,
+		map[string]interface{}{"id": id, "image_url": variants.Full},
+	)
+	if err != nil {
+		return
+	}
+
+	if err := images.DeleteReplaced(oldImageKey, variants); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Warnf("Error on deleting the replaced collection cover image %s", oldImageKey)
+	}
+	return
+}
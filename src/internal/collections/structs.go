@@ -0,0 +1,44 @@
+package collections
+
+import (
+	"github.com/lib/pq"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/storage"
+)
+
+type Collection struct {
+	Id            int               `json:"id" db:"id"`
+	Name          string            `json:"name" db:"name"`
+	Description   string            `json:"description" db:"description"`
+	CoverImageURL storage.ObjectKey `json:"coverImageURL" db:"cover_image_url"`
+	IsPublic      bool              `json:"isPublic" db:"is_public"`
+	IsDefault     bool              `json:"isDefault" db:"is_default"`
+	OwnerName     string            `json:"ownerName" db:"owner_name"`
+	RecipesCount  int               `json:"recipesCount" db:"recipes_count"`
+}
+
+type CollectionDetails struct {
+	Collection
+	Collaborators pq.StringArray           `json:"collaborators" db:"collaborators"`
+	Recipes       []recipes.BaseRecipeInfo `json:"recipes"`
+}
+
+type CollectionRequest struct {
+	Name        string `json:"name" db:"name" valid:"required,minstringlength(2)"`
+	Description string `json:"description" db:"description"`
+	IsPublic    bool   `json:"isPublic" db:"is_public"`
+	OwnerId     int    `json:"-" db:"owner_id"`
+	Id          int    `json:"-" db:"id"`
+}
+
+type CollectionRecipeRequest struct {
+	RecipeName string `json:"recipeName" db:"recipe_name" valid:"required"`
+}
+
+type CollectionOrderRequest struct {
+	RecipeNames []string `json:"recipeNames" valid:"required"`
+}
+
+type CollaboratorRequest struct {
+	Username string `json:"username" db:"username" valid:"required"`
+}
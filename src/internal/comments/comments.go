@@ -16,6 +16,9 @@ func GetLatestComments() (comments []Comment, err error) {
 				FROM comments
 						 JOIN recipes ON comments.target_recipe_id = recipes.id
 						 JOIN users ON comments.owner_id = users.id
+				WHERE comments.deleted_at IS NULL
+				  AND recipes.deleted_at IS NULL
+				  AND users.deleted_at IS NULL
 				ORDER BY created_at DESC
 				LIMIT 6;`,
 	)
@@ -37,29 +40,55 @@ func GetCommentsForRecipe(recipeName string) (comments []Comment, err error) {
 						 JOIN recipes ON comments.target_recipe_id = recipes.id
 						 JOIN users ON comments.owner_id = users.id
 				WHERE recipes.recipe_name = :recipe_name
+				  AND comments.deleted_at IS NULL
+				  AND recipes.deleted_at IS NULL
+				  AND users.deleted_at IS NULL
 				ORDER BY created_at DESC;`,
 		map[string]interface{}{"recipe_name": recipeName},
 	)
 	return
 }
 
+// GetFromUser gets the comments the given user wrote, the most recent first
+func GetFromUser(username string) (comments []Comment, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&comments,
+		`SELECT comments.id,
+    				   comments.content,
+					   comments.created_at,
+					   recipes.recipe_name,
+					   users.username,
+					   COALESCE(users.avatar_url, '') AS avatar_url
+				FROM comments
+						 JOIN recipes ON comments.target_recipe_id = recipes.id
+						 JOIN users ON comments.owner_id = users.id
+				WHERE users.username = :username
+				  AND comments.deleted_at IS NULL
+				  AND recipes.deleted_at IS NULL
+				ORDER BY created_at DESC;`,
+		map[string]interface{}{"username": username},
+	)
+	return
+}
+
 // Edit edits a comment
 func Edit(data CommentEditData) (result Comment, err error) {
 	err = database.GetSingleRecordNamedQuery(
 		&result,
-		`UPDATE comments SET content = :content WHERE id = :id
+		`UPDATE comments SET content = :content WHERE id = :id AND deleted_at IS NULL
 				RETURNING *`,
 		data,
 	)
 	return
 }
 
-// Delete deletes a comment
+// Delete moves a comment to the trash. It is purged once the trash retention period passes.
 func Delete(id int) (err error) {
-	_, err = database.ExecuteNamedQuery(
-		`DELETE
-				FROM comments
-				WHERE id = :id;`,
+	var deletedId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&deletedId,
+		`UPDATE comments SET deleted_at = NOW() WHERE id = :id AND deleted_at IS NULL RETURNING id;`,
 		map[string]interface{}{"id": id},
 	)
 	return
@@ -70,7 +99,7 @@ func Create(data CommentData) (result Comment, err error) {
 	err = database.GetSingleRecordNamedQuery(
 		&result,
 		`INSERT INTO comments(content, created_at, owner_id, target_recipe_id)
-				VALUES (:content, Now(), :owner_id, (SELECT id FROM recipes WHERE recipe_name = :recipe_name))
+				VALUES (:content, Now(), :owner_id, (SELECT id FROM recipes WHERE recipe_name = :recipe_name AND deleted_at IS NULL))
 				RETURNING *`,
 		data,
 	)
@@ -79,7 +108,7 @@ func Create(data CommentData) (result Comment, err error) {
 
 // Count retrieves the total count of the comments
 func Count() (count int, err error) {
-	err = database.GetSingleRecord(&count, `SELECT COUNT(id) FROM comments;`)
+	err = database.GetSingleRecord(&count, `SELECT COUNT(id) FROM comments WHERE deleted_at IS NULL;`)
 	return
 }
 
@@ -96,6 +125,9 @@ func GetAll() (comments []Comment, err error) {
 				FROM comments
 						 JOIN recipes ON comments.target_recipe_id = recipes.id
 						 JOIN users ON comments.owner_id = users.id
+				WHERE comments.deleted_at IS NULL
+				  AND recipes.deleted_at IS NULL
+				  AND users.deleted_at IS NULL
 				ORDER BY created_at DESC;`,
 	)
 	return
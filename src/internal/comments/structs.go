@@ -27,3 +27,8 @@ type CommentEditData struct {
 	CommentIdData
 	Content string `json:"content" db:"content" valid:"required"`
 }
+
+type DeletedComment struct {
+	Comment
+	DeletedAt time.Time `json:"deletedAt" db:"deleted_at"`
+}
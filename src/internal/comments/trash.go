@@ -0,0 +1,50 @@
+package comments
+
+import (
+	"recipes-v2-server/database"
+)
+
+// GetDeleted gets the comments in the trash, the most recently deleted first
+func GetDeleted() (comments []DeletedComment, err error) {
+	err = database.GetMultipleRecords(
+		&comments,
+		`SELECT comments.id,
+    				   comments.content,
+					   comments.created_at,
+					   recipes.recipe_name,
+					   users.username,
+					   COALESCE(users.avatar_url, '') AS avatar_url,
+					   comments.deleted_at
+				FROM comments
+						 JOIN recipes ON comments.target_recipe_id = recipes.id
+						 JOIN users ON comments.owner_id = users.id
+				WHERE comments.deleted_at IS NOT NULL
+				ORDER BY comments.deleted_at DESC;`,
+	)
+	return
+}
+
+// Restore takes a comment out of the trash
+func Restore(id int) (err error) {
+	var restoredId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&restoredId,
+		`UPDATE comments SET deleted_at = NULL WHERE id = :id AND deleted_at IS NOT NULL RETURNING id;`,
+		map[string]interface{}{"id": id},
+	)
+	return
+}
+
+// PurgeDeleted permanently deletes the comments that have been in the trash for longer than the given number of days.
+// Returns the number of purged comments.
+func PurgeDeleted(retentionDays int) (purged int64, err error) {
+	result, err := database.ExecuteNamedQuery(
+		`DELETE FROM comments WHERE deleted_at < NOW() - MAKE_INTERVAL(days => :retention_days);`,
+		map[string]interface{}{"retention_days": retentionDays},
+	)
+	if err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,154 @@
+package cooklogs
+
+import (
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/storage"
+	"recipes-v2-server/utils"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ParseCookedOn parses the day a recipe was cooked on in the YYYY-MM-DD format. Days in the future are rejected.
+func ParseCookedOn(date string) (cookedOn time.Time, err error) {
+	cookedOn, err = time.Parse(dateLayout, date)
+	if err != nil {
+		return cookedOn, errors.New("cookedOn should be in the YYYY-MM-DD format")
+	}
+	// a day ahead leaves room for the users whose time zone is already in the next day
+	if cookedOn.After(time.Now().UTC().AddDate(0, 0, 1)) {
+		return cookedOn, errors.New("cookedOn should not be in the future")
+	}
+	return
+}
+
+// GetForRecipe gets the cook logs of the recipe, the most recently cooked first
+func GetForRecipe(recipeName string) (cookLogs []CookLog, err error) {
+	return getForRecipe(recipeName, false)
+}
+
+// GetPhotos gets the cook logs of the recipe that have a photo, the community gallery of the recipe
+func GetPhotos(recipeName string) (cookLogs []CookLog, err error) {
+	return getForRecipe(recipeName, true)
+}
+
+// GetFromUser gets the cook logs the user posted, the most recently cooked first
+func GetFromUser(userId int) (cookLogs []CookLog, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&cookLogs,
+		`SELECT cook_logs.id,
+					   recipes.recipe_name,
+					   users.username,
+					   COALESCE(users.avatar_url, '') AS avatar_url,
+					   cook_logs.cooked_on,
+					   cook_logs.photo_url,
+					   cook_logs.note,
+					   COALESCE(cook_logs.rating, 0)  AS rating,
+					   cook_logs.created_at
+				FROM cook_logs
+						 JOIN recipes ON recipes.id = cook_logs.recipe_id
+						 JOIN users ON users.id = cook_logs.user_id
+				WHERE cook_logs.user_id = :user_id
+				  AND recipes.deleted_at IS NULL
+				ORDER BY cook_logs.cooked_on DESC, cook_logs.id DESC;`,
+		map[string]interface{}{"user_id": userId},
+	)
+	return
+}
+
+func getForRecipe(recipeName string, withPhotoOnly bool) (cookLogs []CookLog, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&cookLogs,
+		`SELECT cook_logs.id,
+					   recipes.recipe_name,
+					   users.username,
+					   COALESCE(users.avatar_url, '') AS avatar_url,
+					   cook_logs.cooked_on,
+					   cook_logs.photo_url,
+					   cook_logs.note,
+					   COALESCE(cook_logs.rating, 0)  AS rating,
+					   cook_logs.created_at
+				FROM cook_logs
+						 JOIN recipes ON recipes.id = cook_logs.recipe_id
+						 JOIN users ON users.id = cook_logs.user_id
+				WHERE recipes.recipe_name = :recipe_name
+				  AND recipes.deleted_at IS NULL
+				  AND users.deleted_at IS NULL
+				  AND (NOT CAST(:with_photo_only AS BOOLEAN) OR cook_logs.photo_url != '')
+				ORDER BY cook_logs.cooked_on DESC, cook_logs.id DESC;`,
+		map[string]interface{}{"recipe_name": recipeName, "with_photo_only": withPhotoOnly},
+	)
+	return
+}
+
+// Create records that the user cooked an approved recipe. The photo is optional, pass nil when there is none.
+func Create(request CookLogRequest, photo io.Reader) (cookLog CookLog, err error) {
+	if photo != nil {
+		var variants images.Variants
+		variants, err = images.Upload(photo, images.CookLogPhoto)
+		if err != nil {
+			return
+		}
+		request.PhotoURL = variants.Full
+	}
+
+	err = database.GetSingleRecordNamedQuery(
+		&cookLog,
+		`WITH inserted_cook_log AS (INSERT INTO cook_logs (user_id, recipe_id, cooked_on, photo_url, note, rating, created_at)
+					SELECT :user_id, recipes.id, CAST(:cooked_on AS DATE), :photo_url, :note, NULLIF(:rating, 0), NOW()
+					FROM recipes
+					WHERE recipe_name = :recipe_name AND status = 'APPROVED' AND deleted_at IS NULL
+					RETURNING *)
+
+				SELECT inserted_cook_log.id,
+					   :recipe_name                         AS recipe_name,
+					   users.id                             AS user_id,
+					   users.username,
+					   COALESCE(users.avatar_url, '')       AS avatar_url,
+					   inserted_cook_log.cooked_on,
+					   inserted_cook_log.photo_url,
+					   inserted_cook_log.note,
+					   COALESCE(inserted_cook_log.rating, 0) AS rating,
+					   inserted_cook_log.created_at
+				FROM inserted_cook_log
+						 JOIN users ON users.id = inserted_cook_log.user_id;`,
+		request,
+	)
+	if err != nil && request.PhotoURL != "" {
+		deletePhoto(request.PhotoURL)
+	}
+	return
+}
+
+// Delete removes a cook log of the user together with its photo
+func Delete(userId, id int) (err error) {
+	var photoURL storage.ObjectKey
+
+	err = database.GetSingleRecordNamedQuery(
+		&photoURL,
+		`DELETE FROM cook_logs WHERE id = :id AND user_id = :user_id RETURNING photo_url;`,
+		map[string]interface{}{"id": id, "user_id": userId},
+	)
+	if err != nil {
+		return
+	}
+
+	if photoURL != "" {
+		deletePhoto(photoURL)
+	}
+	return
+}
+
+// deletePhoto only logs a failed clean up, the photos left behind are removed by the orphans job
+func deletePhoto(photoURL storage.ObjectKey) {
+	if err := images.Delete(photoURL); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Warnf("Error on deleting the cook log photo %s", photoURL)
+	}
+}
@@ -0,0 +1,28 @@
+package cooklogs
+
+import (
+	"recipes-v2-server/storage"
+	"time"
+)
+
+type CookLog struct {
+	Id         int               `json:"id" db:"id"`
+	RecipeName string            `json:"recipeName" db:"recipe_name"`
+	Username   string            `json:"username" db:"username"`
+	UserId     int               `json:"-" db:"user_id"`
+	UserAvatar storage.ObjectKey `json:"userAvatar" db:"avatar_url"`
+	CookedOn   time.Time         `json:"cookedOn" db:"cooked_on"`
+	PhotoURL   storage.ObjectKey `json:"photoURL,omitempty" db:"photo_url"`
+	Note       string            `json:"note,omitempty" db:"note"`
+	Rating     int               `json:"rating,omitempty" db:"rating"`
+	CreatedAt  time.Time         `json:"createdAt" db:"created_at"`
+}
+
+type CookLogRequest struct {
+	CookedOn   string            `json:"cookedOn" form:"cookedOn" db:"cooked_on" valid:"required"`
+	Note       string            `json:"note" form:"note" db:"note" valid:"maxstringlength(1000)"`
+	Rating     int               `json:"rating" form:"rating" db:"rating" valid:"range(0|5)"`
+	RecipeName string            `json:"-" db:"recipe_name"`
+	UserId     int               `json:"-" db:"user_id"`
+	PhotoURL   storage.ObjectKey `json:"-" db:"photo_url"`
+}
@@ -0,0 +1,64 @@
+package exports
+
+import (
+	"errors"
+	"recipes-v2-server/internal/collections"
+	"recipes-v2-server/internal/comments"
+	"recipes-v2-server/internal/cooklogs"
+	"recipes-v2-server/internal/mealplans"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/internal/users"
+	"time"
+)
+
+// ErrNotOwner is returned when someone else than the user asks for the export. It holds the private notes, so not even
+// the administrators get it.
+var ErrNotOwner = errors.New("only the user can export their own data")
+
+// ExportUserData collects everything the given user created on the site - the profile, recipes, favourites,
+// collections, comments, meal plan, cook logs and private notes. Only the user themselves can export it.
+func ExportUserData(username string, requesterId int) (export UserDataExport, err error) {
+	export.ExportedAt = time.Now().UTC()
+
+	export.Profile, err = users.GetUser(username)
+	if err != nil {
+		return
+	}
+	userId := export.Profile.Id
+	if userId != requesterId {
+		return UserDataExport{}, ErrNotOwner
+	}
+
+	export.Recipes, err = recipes.GetRecipesFromUser(username)
+	if err != nil {
+		return
+	}
+
+	export.Favourites, err = recipes.GetFavourites(username)
+	if err != nil {
+		return
+	}
+
+	export.Collections, err = collections.GetForUser(username, userId)
+	if err != nil {
+		return
+	}
+
+	export.Comments, err = comments.GetFromUser(username)
+	if err != nil {
+		return
+	}
+
+	export.MealPlan, err = mealplans.GetAll(userId)
+	if err != nil {
+		return
+	}
+
+	export.CookLogs, err = cooklogs.GetFromUser(userId)
+	if err != nil {
+		return
+	}
+
+	export.Notes, err = recipes.GetAllNotes(userId)
+	return
+}
@@ -0,0 +1,23 @@
+package exports
+
+import (
+	"recipes-v2-server/internal/collections"
+	"recipes-v2-server/internal/comments"
+	"recipes-v2-server/internal/cooklogs"
+	"recipes-v2-server/internal/mealplans"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/internal/users"
+	"time"
+)
+
+type UserDataExport struct {
+	ExportedAt  time.Time                 `json:"exportedAt"`
+	Profile     users.User                `json:"profile"`
+	Recipes     []recipes.BaseRecipeInfo  `json:"recipes"`
+	Favourites  []recipes.BaseRecipeInfo  `json:"favourites"`
+	Collections []collections.Collection  `json:"collections"`
+	Comments    []comments.Comment        `json:"comments"`
+	MealPlan    []mealplans.MealPlanEntry `json:"mealPlan"`
+	CookLogs    []cooklogs.CookLog        `json:"cookLogs"`
+	Notes       []recipes.RecipeNote      `json:"notes"`
+}
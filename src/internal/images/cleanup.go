@@ -0,0 +1,232 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"recipes-v2-server/database"
+	"recipes-v2-server/storage"
+	"recipes-v2-server/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultOrphanGracePeriodHours = 24
+
+var orphanGracePeriodHours = defaultOrphanGracePeriodHours
+
+// imageReferences selects the image keys stored on users, recipes, recipe steps, recipe galleries, collections and
+// cook logs
+const imageReferences = `SELECT avatar_url AS key FROM users
+						 UNION
+						 SELECT cover_photo_url FROM users
+						 UNION
+						 SELECT image_url FROM recipes
+						 UNION
+						 SELECT step ->> 'imageURL'
+						 FROM recipes,
+							  JSON_ARRAY_ELEMENTS(CAST(recipes.steps AS JSON)) AS step
+						 WHERE JSON_TYPEOF(step) = 'object'
+						 UNION
+						 SELECT image_url FROM recipe_images
+						 UNION
+						 SELECT cover_image_url FROM collections
+						 UNION
+						 SELECT photo_url FROM cook_logs`
+
+// GetOrphanGracePeriod retrieves the hours an unreferenced file is kept for from the config and stores it in memory.
+// Falls back to a day when it is missing or invalid.
+func GetOrphanGracePeriod(gracePeriodHours string) {
+	asNumber, err := strconv.Atoi(gracePeriodHours)
+	if err != nil || asNumber < 1 {
+		orphanGracePeriodHours = defaultOrphanGracePeriodHours
+		return
+	}
+	orphanGracePeriodHours = asNumber
+}
+
+// FindOrphans reports the stored files that no image in the DB references and that are older than the grace period,
+// together with the deletions waiting to be retried. Nothing is deleted.
+func FindOrphans() (report OrphanReport, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.JobTimeout)
+	defer cancel()
+
+	report, _, err = findOrphans(ctx)
+	return
+}
+
+// CollectOrphans deletes the orphaned files and retries the failed deletions of images that are still unreferenced.
+// Deletions that fail again are recorded for the next run. Nothing is deleted while the DB still references images by
+// absolute URLs that do not belong to the active storage - they are logged, so the image keys migration is run first.
+func CollectOrphans() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.JobTimeout)
+	defer cancel()
+
+	report, referenced, err := findOrphans(ctx)
+	if err != nil {
+		return
+	}
+
+	if len(report.UnresolvedURLs) > 0 {
+		for _, url := range report.UnresolvedURLs {
+			utils.
+				GetLogger().
+				WithFields(log.Fields{"url": url}).
+				Warn("Image referenced by an absolute URL outside the active storage, run migrate-image-keys")
+		}
+		return fmt.Errorf("skipped deleting orphaned images, %d images are referenced by absolute URLs outside the active storage", len(report.UnresolvedURLs))
+	}
+
+	var keys, reusedKeys []string
+	isOrphan := map[string]bool{}
+	for _, orphan := range report.Orphans {
+		keys = append(keys, orphan.Key)
+		isOrphan[orphan.Key] = true
+	}
+	for _, failedDeletion := range report.FailedDeletions {
+		if referenced[failedDeletion.Key] {
+			reusedKeys = append(reusedKeys, failedDeletion.Key)
+		} else if !isOrphan[failedDeletion.Key] {
+			keys = append(keys, failedDeletion.Key)
+		}
+	}
+
+	var deletedKeys []string
+	for _, key := range keys {
+		if deleteErr := storage.Delete(key); deleteErr != nil {
+			recordFailedDeletion(key, deleteErr)
+			continue
+		}
+		deletedKeys = append(deletedKeys, key)
+	}
+
+	_, err = database.ExecuteNamedQueryContext(
+		ctx,
+		`WITH delete_variants AS (DELETE FROM image_variants WHERE full_key = ANY (CAST(:keys AS TEXT[]))),
+					 delete_recipe_uploads AS (DELETE FROM recipe_image_uploads WHERE image_key = ANY (CAST(:keys AS TEXT[])))
+
+				DELETE
+				FROM failed_image_deletions
+				WHERE key = ANY (CAST(:keys AS TEXT[]))
+				   OR key = ANY (CAST(:reused_keys AS TEXT[]));`,
+		map[string]interface{}{"keys": pq.StringArray(deletedKeys), "reused_keys": pq.StringArray(reusedKeys)},
+	)
+	if err != nil {
+		return
+	}
+
+	if failed := len(keys) - len(deletedKeys); failed > 0 {
+		err = fmt.Errorf("%d of %d orphaned images could not be deleted", failed, len(keys))
+	}
+	return
+}
+
+func findOrphans(ctx context.Context) (report OrphanReport, referenced map[string]bool, err error) {
+	objects, err := storage.List()
+	if err != nil {
+		return
+	}
+
+	referenced, unresolvedURLs, err := referencedKeys(ctx)
+	if err != nil {
+		return
+	}
+
+	report = OrphanReport{
+		Orphans:          []storage.StoredObject{},
+		GracePeriodHours: orphanGracePeriodHours,
+		FailedDeletions:  []FailedDeletion{},
+		UnresolvedURLs:   unresolvedURLs,
+	}
+	uploadedBefore := time.Now().Add(-time.Duration(orphanGracePeriodHours) * time.Hour)
+	for _, object := range objects {
+		if referenced[object.Key] || object.LastModified.After(uploadedBefore) {
+			continue
+		}
+		report.Orphans = append(report.Orphans, object)
+		report.TotalSize += object.Size
+	}
+
+	err = database.GetMultipleRecordsContext(
+		ctx,
+		&report.FailedDeletions,
+		`SELECT key, attempts, last_error, failed_at FROM failed_image_deletions ORDER BY failed_at;`,
+	)
+	return
+}
+
+// referencedKeys collects the keys of every image the DB points to, including the variants of the referenced images,
+// the direct and resumable uploads that are not confirmed yet and the images uploaded again within the grace period.
+// The absolute URLs that are not under one of the base URLs of the storage are returned separately.
+func referencedKeys(ctx context.Context) (referenced map[string]bool, unresolvedURLs []string, err error) {
+	var keys []string
+
+	err = database.GetMultipleRecordsNamedQueryContext(
+		ctx,
+		&keys,
+		`WITH referenced AS (`+imageReferences+`
+							 UNION
+							 SELECT key FROM pending_uploads
+							 UNION
+							 SELECT UNNEST(chunk_keys) FROM tus_uploads
+							 UNION
+							 SELECT full_key
+							 FROM image_variants
+							 WHERE last_uploaded_at > NOW() - MAKE_INTERVAL(hours => :grace_period_hours))
+
+				SELECT key FROM referenced WHERE COALESCE(key, '') != ''
+				UNION
+				SELECT variant_key
+				FROM image_variants
+						 JOIN referenced ON referenced.key = image_variants.full_key,
+					 UNNEST(ARRAY [card_key, thumbnail_key, full_webp_key, card_webp_key, thumbnail_webp_key]) AS variant_key
+				WHERE variant_key != '';`,
+		map[string]interface{}{"grace_period_hours": orphanGracePeriodHours},
+	)
+	if err != nil {
+		return
+	}
+
+	referenced = map[string]bool{}
+	unresolvedURLs = []string{}
+	for _, key := range keys {
+		key = storage.KeyFromURL(key)
+		if strings.Contains(key, "://") || strings.HasPrefix(key, "//") {
+			unresolvedURLs = append(unresolvedURLs, key)
+			continue
+		}
+		referenced[key] = true
+	}
+	return
+}
+
+// isReferenced checks if anything in the DB still uses the image
+func isReferenced(imageKey storage.ObjectKey) (referenced bool, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&referenced,
+		`SELECT EXISTS(SELECT 1 FROM (`+imageReferences+`) AS referenced WHERE key = :image_key);`,
+		map[string]interface{}{"image_key": imageKey},
+	)
+	return
+}
+
+// recordFailedDeletion keeps the key of a file the storage failed to delete, so the orphans job retries it. Failing to
+// record it only leaves the file to be found by the listing of the storage once the grace period passes.
+func recordFailedDeletion(key string, deleteErr error) {
+	_, err := database.ExecuteNamedQuery(
+		`INSERT INTO failed_image_deletions (key, attempts, last_error, failed_at)
+				VALUES (:key, 1, :last_error, NOW())
+				ON CONFLICT (key) DO UPDATE SET attempts   = failed_image_deletions.attempts + 1,
+												last_error = excluded.last_error,
+												failed_at  = excluded.failed_at;`,
+		map[string]interface{}{"key": key, "last_error": deleteErr.Error()},
+	)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Warnf("Error on recording the failed deletion of %s", key)
+	}
+}
@@ -0,0 +1,181 @@
+package images
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"recipes-v2-server/database"
+	"recipes-v2-server/storage"
+	"slices"
+	"strings"
+)
+
+// variantColumns are the columns of the image_variants table the Variants are read from
+const variantColumns = `full_key, card_key, thumbnail_key, full_webp_key, card_webp_key, thumbnail_webp_key`
+
+// Upload validates the uploaded image against the limits of its kind, processes it into its variants and uploads them
+// to the storage under a key made of the hash of the content and a random part, so uploads never overwrite each other.
+// The same bytes uploaded again reuse the variants that are already stored.
+func Upload(content io.Reader, kind Kind) (variants Variants, err error) {
+	// reading one byte over the limit is enough to reject the file without buffering all of it
+	data, err := io.ReadAll(io.LimitReader(content, kind.MaxBytes+1))
+	if err != nil {
+		return
+	}
+
+	err = Validate(data, kind)
+	if err != nil {
+		return
+	}
+
+	contentHash := sha256.Sum256(data)
+	hash := hex.EncodeToString(contentHash[:])
+
+	variants, err = findByContentHash(hash)
+	if err == nil || err.Error() != "sql: no rows in result set" {
+		return
+	}
+
+	processed, err := process(data)
+	if err != nil {
+		return
+	}
+
+	uploaded, err := putVariants(processed, kind, hash)
+	if err != nil {
+		return
+	}
+
+	err = database.GetSingleRecordNamedQuery(
+		&variants,
+		`INSERT INTO image_variants (full_key, card_key, thumbnail_key, full_webp_key, card_webp_key, thumbnail_webp_key,
+											content_hash, created_at, last_uploaded_at)
+				VALUES (:full_key, :card_key, :thumbnail_key, :full_webp_key, :card_webp_key, :thumbnail_webp_key,
+						:content_hash, NOW(), NOW())
+				ON CONFLICT (content_hash) DO UPDATE SET last_uploaded_at = NOW()
+				RETURNING `+variantColumns+`;`,
+		map[string]interface{}{
+			"full_key":           uploaded.Full,
+			"card_key":           uploaded.Card,
+			"thumbnail_key":      uploaded.Thumbnail,
+			"full_webp_key":      uploaded.FullWebP,
+			"card_webp_key":      uploaded.CardWebP,
+			"thumbnail_webp_key": uploaded.ThumbnailWebP,
+			"content_hash":       hash,
+		},
+	)
+	if err == nil && variants.Full != uploaded.Full {
+		// the same bytes were uploaded concurrently and the other upload was stored first. Failed deletions are
+		// recorded for the orphans job.
+		for _, key := range uploaded.keys() {
+			_ = deleteObject(key)
+		}
+	}
+	return
+}
+
+// findByContentHash gets the variants of an image that was already uploaded with the same content. Reusing them counts
+// as a new upload for the grace period of the orphans job.
+func findByContentHash(hash string) (variants Variants, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&variants,
+		`UPDATE image_variants
+				SET last_uploaded_at = NOW()
+				WHERE content_hash = :content_hash
+				RETURNING `+variantColumns+`;`,
+		map[string]interface{}{"content_hash": hash},
+	)
+	return
+}
+
+// putVariants uploads the processed variants. Their content never changes under a key, so they are cached forever.
+func putVariants(processed []processedVariant, kind Kind, hash string) (variants Variants, err error) {
+	randomPart := make([]byte, 8)
+	if _, err = rand.Read(randomPart); err != nil {
+		return
+	}
+	fileKey := fmt.Sprintf("%s/%s-%s", kind.keyPrefix, hash[:32], hex.EncodeToString(randomPart))
+
+	keys := map[string]storage.ObjectKey{}
+	for _, variant := range processed {
+		fileName := variant.name + "." + variant.extension
+		variantKey := fmt.Sprintf("%s-%s", fileKey, fileName)
+
+		err = storage.Put(variantKey, bytes.NewReader(variant.data), variant.contentType, storage.ImmutableCacheControl)
+		if err != nil {
+			return
+		}
+		keys[fileName] = storage.ObjectKey(variantKey)
+	}
+
+	variants = Variants{
+		Thumbnail:     keys["thumbnail.jpg"],
+		Card:          keys["card.jpg"],
+		Full:          keys["full.jpg"],
+		ThumbnailWebP: keys["thumbnail.webp"],
+		CardWebP:      keys["card.webp"],
+		FullWebP:      keys["full.webp"],
+	}
+	return
+}
+
+// DeleteReplaced removes the variants of the old image from the storage once it was replaced by the current one.
+// Images that are still referenced elsewhere, since the same bytes were uploaded more than once, are kept.
+func DeleteReplaced(oldImageKey storage.ObjectKey, current Variants) (err error) {
+	if oldImageKey == "" || oldImageKey == current.Full {
+		return
+	}
+
+	stillReferenced, err := isReferenced(oldImageKey)
+	if err != nil || stillReferenced {
+		return
+	}
+
+	var old Variants
+
+	err = database.GetSingleRecordNamedQuery(
+		&old,
+		`DELETE FROM image_variants WHERE full_key = :image_key RETURNING `+variantColumns+`;`,
+		map[string]interface{}{"image_key": oldImageKey},
+	)
+	if err != nil {
+		if err.Error() != "sql: no rows in result set" {
+			return
+		}
+		// images uploaded before the processing pipeline have no variants
+		old, err = Variants{Full: oldImageKey}, nil
+	}
+
+	for _, key := range old.keys() {
+		if slices.Contains(current.keys(), key) {
+			continue
+		}
+
+		err = errors.Join(err, deleteObject(key))
+	}
+	return
+}
+
+// Delete removes the image together with all of its variants from the storage
+func Delete(imageKey storage.ObjectKey) error {
+	return DeleteReplaced(imageKey, Variants{})
+}
+
+// deleteObject removes a stored file by its key. Absolute URLs of files that are not in the active storage are left
+// as they are, since they can not be removed from here. Failed deletions are retried by the orphans job.
+func deleteObject(imageKey storage.ObjectKey) error {
+	key := storage.KeyFromURL(string(imageKey))
+	if strings.Contains(key, "://") || strings.HasPrefix(key, "/") {
+		return nil
+	}
+
+	err := storage.Delete(key)
+	if err != nil {
+		recordFailedDeletion(key, err)
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package images
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+)
+
+// maxTextChunkSize limits the decompressed size of a compressed png text chunk
+const maxTextChunkSize = 1 << 20
+
+// metadataSegments returns the text and metadata areas of the image - the APPn and COM segments of a jpeg, the text
+// chunks of a png and the comment and application extensions of a gif. Malformed files end the walk early, they are
+// rejected by the decoding afterwards.
+func metadataSegments(data []byte, format string) [][]byte {
+	switch format {
+	case "jpeg":
+		return jpegMetadataSegments(data)
+	case "png":
+		return pngTextChunks(data)
+	case "gif":
+		return gifExtensions(data)
+	}
+	return nil
+}
+
+func jpegMetadataSegments(data []byte) (segments [][]byte) {
+	for offset := 2; offset+4 <= len(data); {
+		if data[offset] != 0xFF {
+			return
+		}
+		marker := data[offset+1]
+		// fill bytes and the markers without a length
+		if marker == 0xFF {
+			offset++
+			continue
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			offset += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		// the metadata segments are all before the start of the image data
+		if marker == 0xDA || length < 2 || offset+2+length > len(data) {
+			return
+		}
+
+		if (marker >= 0xE0 && marker <= 0xEF) || marker == 0xFE {
+			segments = append(segments, data[offset+4:offset+2+length])
+		}
+		offset += 2 + length
+	}
+	return
+}
+
+func pngTextChunks(data []byte) (chunks [][]byte) {
+	// every chunk has a 4 byte length, a 4 byte type, its data and a 4 byte checksum
+	for offset := 8; offset+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		if length < 0 || offset+12+length > len(data) {
+			return
+		}
+		chunkType := string(data[offset+4 : offset+8])
+		chunkData := data[offset+8 : offset+8+length]
+
+		switch chunkType {
+		case "tEXt":
+			chunks = append(chunks, chunkData)
+		case "zTXt":
+			// keyword, null separator, compression method and the compressed text
+			if keyword, compressed, found := bytes.Cut(chunkData, []byte{0}); found && len(compressed) > 0 {
+				chunks = append(chunks, keyword, inflate(compressed[1:]))
+			}
+		case "iTXt":
+			chunks = append(chunks, chunkData)
+			// keyword, null separator, compression flag, compression method, language and translated keyword
+			// separated by nulls and the text, compressed when the flag is set
+			if keyword, rest, found := bytes.Cut(chunkData, []byte{0}); found && len(rest) > 2 && rest[0] == 1 {
+				parts := bytes.SplitN(rest[2:], []byte{0}, 3)
+				if len(parts) == 3 {
+					chunks = append(chunks, keyword, inflate(parts[2]))
+				}
+			}
+		case "IEND":
+			return
+		}
+		offset += 12 + length
+	}
+	return
+}
+
+func gifExtensions(data []byte) (extensions [][]byte) {
+	// the header is followed by the logical screen descriptor and the optional global color table
+	if len(data) < 13 {
+		return
+	}
+	offset := 13
+	if data[10]&0x80 != 0 {
+		offset += 3 << (int(data[10]&0x07) + 1)
+	}
+
+	for offset < len(data) {
+		switch data[offset] {
+		case 0x21:
+			if offset+2 > len(data) {
+				return
+			}
+			label := data[offset+1]
+			var content []byte
+			content, offset = gifSubBlocks(data, offset+2)
+			if label == 0xFE || label == 0xFF {
+				extensions = append(extensions, content)
+			}
+		case 0x2C:
+			// the image descriptor is followed by the optional local color table, the minimum code size and the
+			// image data sub-blocks
+			if offset+10 > len(data) {
+				return
+			}
+			flags := data[offset+9]
+			offset += 10
+			if flags&0x80 != 0 {
+				offset += 3 << (int(flags&0x07) + 1)
+			}
+			_, offset = gifSubBlocks(data, offset+1)
+		default:
+			return
+		}
+	}
+	return
+}
+
+// gifSubBlocks joins the data sub-blocks starting at the given offset and returns the offset after their terminator
+func gifSubBlocks(data []byte, offset int) (content []byte, next int) {
+	for offset < len(data) {
+		size := int(data[offset])
+		offset++
+		if size == 0 {
+			return content, offset
+		}
+		if offset+size > len(data) {
+			return content, len(data)
+		}
+		content = append(content, data[offset:offset+size]...)
+		offset += size
+	}
+	return content, len(data)
+}
+
+// inflate decompresses a zlib stream of a png text chunk. Broken streams return what could be read.
+func inflate(compressed []byte) []byte {
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	text, _ := io.ReadAll(io.LimitReader(reader, maxTextChunkSize))
+	return text
+}
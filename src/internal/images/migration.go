@@ -0,0 +1,63 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"recipes-v2-server/database"
+)
+
+// imageColumns are the columns that keep the keys of stored images
+var imageColumns = []struct {
+	table  string
+	column string
+}{
+	{"users", "avatar_url"},
+	{"users", "cover_photo_url"},
+	{"recipes", "image_url"},
+	{"recipe_images", "image_url"},
+	{"collections", "cover_image_url"},
+	{"cook_logs", "photo_url"},
+	{"image_variants", "full_key"},
+	{"image_variants", "card_key"},
+	{"image_variants", "thumbnail_key"},
+}
+
+// MigrateURLsToKeys rewrites the images stored as absolute URLs under one of the given base URLs to object keys.
+// Returns the number of rewritten rows per table and column.
+func MigrateURLsToKeys(baseURLs []string) (migrated map[string]int64, err error) {
+	migrated = map[string]int64{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.JobTimeout)
+	defer cancel()
+
+	for _, imageColumn := range imageColumns {
+		for _, baseURL := range baseURLs {
+			var rows int64
+			rows, err = trimBaseURL(ctx, imageColumn.table, imageColumn.column, baseURL)
+			if err != nil {
+				return
+			}
+			migrated[imageColumn.table+"."+imageColumn.column] += rows
+		}
+	}
+	return
+}
+
+func trimBaseURL(ctx context.Context, table, column, baseURL string) (rows int64, err error) {
+	result, err := database.ExecuteNamedQueryContext(
+		ctx,
+		fmt.Sprintf(
+			`UPDATE %[1]s
+					SET %[2]s = SUBSTRING(%[2]s FROM LENGTH(:base_url) + 1)
+					WHERE LEFT(%[2]s, LENGTH(:base_url)) = :base_url
+					  AND LENGTH(%[2]s) > LENGTH(:base_url);`,
+			table,
+			column,
+		),
+		map[string]interface{}{"base_url": baseURL},
+	)
+	if err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,218 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/gen2brain/webp"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"math"
+)
+
+const (
+	jpegQuality = 82
+	webpQuality = 80
+	// maxPixels guards against decompression bombs - small files that decode to huge images
+	maxPixels          = 50_000_000
+	exifOrientationTag = 0x0112
+)
+
+// variantSpecs are ordered from the largest to the smallest, so every variant is resized from the previous one.
+// Images are never upscaled.
+var variantSpecs = []variantSpec{
+	{name: "full", maxSide: 1920},
+	{name: "card", maxSide: 800},
+	{name: "thumbnail", maxSide: 320},
+}
+
+// process decodes the already validated image, rotates it according to its EXIF orientation and re-encodes every
+// variant as jpeg and as webp. Re-encoding drops all of the metadata of the original file.
+func process(data []byte) (variants []processedVariant, err error) {
+	decoded, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, &ValidationError{Code: "corrupted_image", Message: fmt.Sprintf("the file is not a valid %s image", format)}
+	}
+
+	resized := orient(toRGBA(decoded), exifOrientation(data))
+
+	for _, spec := range variantSpecs {
+		resized = resize(resized, spec.maxSide)
+
+		var jpegBuffer, webpBuffer bytes.Buffer
+		err = jpeg.Encode(&jpegBuffer, resized, &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return
+		}
+
+		err = webp.Encode(&webpBuffer, resized, webp.Options{Quality: webpQuality, Method: webp.DefaultMethod})
+		if err != nil {
+			return
+		}
+
+		variants = append(variants,
+			processedVariant{name: spec.name, extension: "jpg", contentType: "image/jpeg", data: jpegBuffer.Bytes()},
+			processedVariant{name: spec.name, extension: "webp", contentType: "image/webp", data: webpBuffer.Bytes()},
+		)
+	}
+	return
+}
+
+// toRGBA copies the image onto a white background, since jpeg has no transparency
+func toRGBA(source image.Image) *image.RGBA {
+	bounds := source.Bounds()
+	result := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	draw.Draw(result, result.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(result, result.Bounds(), source, bounds.Min, draw.Over)
+	return result
+}
+
+// exifOrientation reads the orientation tag from the EXIF segment of a jpeg file. Anything else is treated as
+// already upright.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for offset := 2; offset+4 <= len(data); {
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		// the metadata segments are all before the start of the image data
+		if data[offset] != 0xFF || marker == 0xDA || length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+
+		if marker == 0xE1 {
+			if orientation, found := tiffOrientation(data[offset+4 : offset+2+length]); found {
+				return orientation
+			}
+		}
+		offset += 2 + length
+	}
+	return 1
+}
+
+func tiffOrientation(segment []byte) (int, bool) {
+	if len(segment) < 14 || string(segment[:6]) != "Exif\x00\x00" {
+		return 0, false
+	}
+	tiff := segment[6:]
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, false
+	}
+
+	directory := int(order.Uint32(tiff[4:]))
+	if directory+2 > len(tiff) {
+		return 0, false
+	}
+
+	for index := 0; index < int(order.Uint16(tiff[directory:])); index++ {
+		entry := directory + 2 + index*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			return int(order.Uint16(tiff[entry+8:])), true
+		}
+	}
+	return 0, false
+}
+
+// orient rotates and mirrors the image so that it is displayed upright without its EXIF orientation
+func orient(source *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return source
+	}
+
+	width, height := source.Rect.Dx(), source.Rect.Dy()
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	if orientation >= 5 {
+		result = image.NewRGBA(image.Rect(0, 0, height, width))
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			targetX, targetY := orientedPoint(x, y, width, height, orientation)
+			result.SetRGBA(targetX, targetY, source.RGBAAt(x, y))
+		}
+	}
+	return result
+}
+
+func orientedPoint(x, y, width, height, orientation int) (int, int) {
+	switch orientation {
+	case 2:
+		return width - 1 - x, y
+	case 3:
+		return width - 1 - x, height - 1 - y
+	case 4:
+		return x, height - 1 - y
+	case 5:
+		return y, x
+	case 6:
+		return height - 1 - y, x
+	case 7:
+		return height - 1 - y, width - 1 - x
+	default:
+		return y, width - 1 - x
+	}
+}
+
+// resize scales the image down to fit in a square with the given side by averaging the source pixels that fall
+// into every target pixel
+func resize(source *image.RGBA, maxSide int) *image.RGBA {
+	width, height := source.Rect.Dx(), source.Rect.Dy()
+	if width <= maxSide && height <= maxSide {
+		return source
+	}
+
+	scale := float64(maxSide) / float64(max(width, height))
+	targetWidth := max(1, int(math.Round(float64(width)*scale)))
+	targetHeight := max(1, int(math.Round(float64(height)*scale)))
+	result := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+
+	for targetY := 0; targetY < targetHeight; targetY++ {
+		fromY := targetY * height / targetHeight
+		toY := max(fromY+1, (targetY+1)*height/targetHeight)
+
+		for targetX := 0; targetX < targetWidth; targetX++ {
+			fromX := targetX * width / targetWidth
+			toX := max(fromX+1, (targetX+1)*width/targetWidth)
+
+			result.SetRGBA(targetX, targetY, averageColor(source, fromX, fromY, toX, toY))
+		}
+	}
+	return result
+}
+
+func averageColor(source *image.RGBA, fromX, fromY, toX, toY int) color.RGBA {
+	var sum [4]int
+	for y := fromY; y < toY; y++ {
+		for x := fromX; x < toX; x++ {
+			pixel := source.Pix[source.PixOffset(x, y):]
+			for channel := range sum {
+				sum[channel] += int(pixel[channel])
+			}
+		}
+	}
+
+	count := (toX - fromX) * (toY - fromY)
+	return color.RGBA{
+		R: uint8(sum[0] / count),
+		G: uint8(sum[1] / count),
+		B: uint8(sum[2] / count),
+		A: uint8(sum[3] / count),
+	}
+}
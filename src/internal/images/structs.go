@@ -0,0 +1,60 @@
+package images
+
+import (
+	"recipes-v2-server/storage"
+	"time"
+)
+
+// Variants are the keys of the resized copies of an uploaded image, sent to the clients as URLs. Every size is stored
+// as jpeg and as webp, except for the images uploaded before the webp variants were added. The full jpeg variant key
+// is the one stored on the recipe, user or collection.
+type Variants struct {
+	Thumbnail     storage.ObjectKey `json:"thumbnail" db:"thumbnail_key"`
+	Card          storage.ObjectKey `json:"card" db:"card_key"`
+	Full          storage.ObjectKey `json:"full" db:"full_key"`
+	ThumbnailWebP storage.ObjectKey `json:"thumbnailWebP,omitempty" db:"thumbnail_webp_key"`
+	CardWebP      storage.ObjectKey `json:"cardWebP,omitempty" db:"card_webp_key"`
+	FullWebP      storage.ObjectKey `json:"fullWebP,omitempty" db:"full_webp_key"`
+}
+
+// keys lists the keys of all stored variants
+func (variants Variants) keys() (keys []storage.ObjectKey) {
+	for _, key := range []storage.ObjectKey{
+		variants.Full, variants.Card, variants.Thumbnail, variants.FullWebP, variants.CardWebP, variants.ThumbnailWebP,
+	} {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return
+}
+
+// OrphanReport lists the stored files the orphans job would delete. Nothing is deleted while the DB references images
+// by absolute URLs outside the active storage, since those can be the orphans' own files under an old domain.
+type OrphanReport struct {
+	Orphans          []storage.StoredObject `json:"orphans"`
+	TotalSize        int64                  `json:"totalSize"`
+	GracePeriodHours int                    `json:"gracePeriodHours"`
+	FailedDeletions  []FailedDeletion       `json:"failedDeletions"`
+	UnresolvedURLs   []string               `json:"unresolvedURLs"`
+}
+
+// FailedDeletion is a file the storage failed to delete, retried by the orphans job
+type FailedDeletion struct {
+	Key       string    `json:"key" db:"key"`
+	Attempts  int       `json:"attempts" db:"attempts"`
+	LastError string    `json:"lastError" db:"last_error"`
+	FailedAt  time.Time `json:"failedAt" db:"failed_at"`
+}
+
+type variantSpec struct {
+	name    string
+	maxSide int
+}
+
+type processedVariant struct {
+	name        string
+	extension   string
+	contentType string
+	data        []byte
+}
@@ -0,0 +1,237 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// Kind describes what an uploaded image is used for, the limits it has to fit in and the storage prefix of its keys
+type Kind struct {
+	Name         string
+	MaxBytes     int64
+	MinDimension int
+	MaxDimension int
+	keyPrefix    string
+}
+
+var (
+	Avatar       = Kind{Name: "avatar", MaxBytes: 2 << 20, MinDimension: 64, MaxDimension: 4096, keyPrefix: "avatars"}
+	CoverImage   = Kind{Name: "cover image", MaxBytes: 8 << 20, MinDimension: 320, MaxDimension: 8000, keyPrefix: "covers"}
+	RecipeImage  = Kind{Name: "recipe image", MaxBytes: 10 << 20, MinDimension: 320, MaxDimension: 8000, keyPrefix: "recipes"}
+	StepImage    = Kind{Name: "step image", MaxBytes: 10 << 20, MinDimension: 160, MaxDimension: 8000, keyPrefix: "steps"}
+	CookLogPhoto = Kind{Name: "cook log photo", MaxBytes: 10 << 20, MinDimension: 320, MaxDimension: 8000, keyPrefix: "cook-logs"}
+)
+
+// ValidationError describes why an uploaded file was rejected. It is returned as is to the client.
+type ValidationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (validationError *ValidationError) Error() string {
+	return validationError.Message
+}
+
+// signatures are the magic bytes the supported formats start with
+var signatures = map[string][][]byte{
+	"jpeg": {{0xFF, 0xD8, 0xFF}},
+	"png":  {{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}},
+	"gif":  {[]byte("GIF87a"), []byte("GIF89a")},
+}
+
+// markupMarkers are looked for in the text and metadata areas of the file, where html, svg or scripts can be hidden
+// in an otherwise valid image. The compressed pixel data is not scanned, since the markers appear in it by chance.
+var markupMarkers = [][]byte{
+	[]byte("<svg"), []byte("<script"), []byte("<html"), []byte("<?php"), []byte("<?xml"), []byte("<!doctype"),
+	[]byte("javascript:"),
+}
+
+// SniffFormat detects the image format by the magic bytes at the start of the file. The content type sent by the
+// client is never trusted.
+func SniffFormat(header []byte) (string, error) {
+	for format, formatSignatures := range signatures {
+		for _, signature := range formatSignatures {
+			if bytes.HasPrefix(header, signature) {
+				return format, nil
+			}
+		}
+	}
+	return "", &ValidationError{Code: "unsupported_format", Message: "the file is not a supported image, expected jpeg, png or gif"}
+}
+
+// Validate checks the uploaded file by its content against the limits of the given kind - magic bytes, markup embedded
+// in the metadata, trailing data after the end of the image and pixel dimensions
+func Validate(data []byte, kind Kind) (err error) {
+	if int64(len(data)) > kind.MaxBytes {
+		return &ValidationError{
+			Code:    "file_too_large",
+			Message: fmt.Sprintf("the %s should be at most %d MB", kind.Name, kind.MaxBytes>>20),
+		}
+	}
+
+	format, err := SniffFormat(data)
+	if err != nil {
+		return
+	}
+
+	for _, segment := range metadataSegments(data, format) {
+		lowercase := bytes.ToLower(segment)
+		for _, marker := range markupMarkers {
+			if bytes.Contains(lowercase, marker) {
+				return &ValidationError{Code: "embedded_markup", Message: "the image contains embedded markup or scripts"}
+			}
+		}
+	}
+
+	end := imageEnd(data, format)
+	if end < 0 {
+		return &ValidationError{Code: "corrupted_image", Message: fmt.Sprintf("the file is not a valid %s image", format)}
+	}
+
+	// archives and scripts are smuggled inside otherwise valid images by appending them after the end of the image.
+	// Zero padding is allowed, since some cameras add it.
+	if len(bytes.Trim(data[end:], "\x00")) > 0 {
+		return &ValidationError{Code: "trailing_data", Message: "the file contains data after the end of the image"}
+	}
+
+	config, decodedFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decodedFormat != format {
+		return &ValidationError{Code: "corrupted_image", Message: fmt.Sprintf("the file is not a valid %s image", format)}
+	}
+
+	return validateDimensions(config, kind)
+}
+
+func validateDimensions(config image.Config, kind Kind) error {
+	if config.Width > kind.MaxDimension || config.Height > kind.MaxDimension || config.Width*config.Height > maxPixels {
+		return &ValidationError{
+			Code:    "dimensions_too_large",
+			Message: fmt.Sprintf("the %s should be at most %dx%d pixels", kind.Name, kind.MaxDimension, kind.MaxDimension),
+		}
+	}
+
+	if config.Width < kind.MinDimension || config.Height < kind.MinDimension {
+		return &ValidationError{
+			Code:    "dimensions_too_small",
+			Message: fmt.Sprintf("the %s should be at least %dx%d pixels", kind.Name, kind.MinDimension, kind.MinDimension),
+		}
+	}
+	return nil
+}
+
+// imageEnd finds where the image data of the file ends by walking its structure forward from the start, or returns -1
+// for files cut before their end marker. The end marker bytes can appear inside the metadata and the pixel data of a
+// valid image, so searching for them would hide data appended after the real end.
+func imageEnd(data []byte, format string) int {
+	switch format {
+	case "jpeg":
+		return jpegEnd(data)
+	case "png":
+		return pngEnd(data)
+	case "gif":
+		return gifEnd(data)
+	}
+	return -1
+}
+
+func jpegEnd(data []byte) int {
+	for offset := 2; offset+2 <= len(data); {
+		if data[offset] != 0xFF {
+			return -1
+		}
+		marker := data[offset+1]
+		switch {
+		case marker == 0xD9:
+			return offset + 2
+		// fill bytes and the markers without a length
+		case marker == 0xFF:
+			offset++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			offset += 2
+			continue
+		}
+
+		if offset+4 > len(data) {
+			return -1
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return -1
+		}
+		offset += 2 + length
+
+		if marker == 0xDA {
+			offset = jpegScanEnd(data, offset)
+		}
+	}
+	return -1
+}
+
+// jpegScanEnd skips the entropy coded data of a scan and returns the offset of the marker after it. Inside the data a
+// 0xFF byte is followed by a zero byte or a restart marker.
+func jpegScanEnd(data []byte, offset int) int {
+	for ; offset+1 < len(data); offset++ {
+		if data[offset] != 0xFF {
+			continue
+		}
+		next := data[offset+1]
+		if next != 0x00 && next != 0xFF && (next < 0xD0 || next > 0xD7) {
+			return offset
+		}
+	}
+	return len(data)
+}
+
+func pngEnd(data []byte) int {
+	// every chunk has a 4 byte length, a 4 byte type, its data and a 4 byte checksum
+	for offset := 8; offset+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		if length < 0 || offset+12+length > len(data) {
+			return -1
+		}
+		if string(data[offset+4:offset+8]) == "IEND" {
+			return offset + 12 + length
+		}
+		offset += 12 + length
+	}
+	return -1
+}
+
+func gifEnd(data []byte) int {
+	// the header is followed by the logical screen descriptor and the optional global color table
+	if len(data) < 13 {
+		return -1
+	}
+	offset := 13
+	if data[10]&0x80 != 0 {
+		offset += 3 << (int(data[10]&0x07) + 1)
+	}
+
+	for offset < len(data) {
+		switch data[offset] {
+		case 0x3B:
+			return offset + 1
+		case 0x21:
+			if offset+2 > len(data) {
+				return -1
+			}
+			_, offset = gifSubBlocks(data, offset+2)
+		case 0x2C:
+			if offset+10 > len(data) {
+				return -1
+			}
+			flags := data[offset+9]
+			offset += 10
+			if flags&0x80 != 0 {
+				offset += 3 << (int(flags&0x07) + 1)
+			}
+			_, offset = gifSubBlocks(data, offset+1)
+		default:
+			return -1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package mealplans
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"recipes-v2-server/database"
+)
+
+// CreateCalendarToken issues a new calendar feed token for the user, revoking the previous one. The token only gives
+// access to the meal plan calendar and only its hash is stored.
+func CreateCalendarToken(userId int) (token string, err error) {
+	randomPart := make([]byte, 32)
+	if _, err = rand.Read(randomPart); err != nil {
+		return
+	}
+	token = hex.EncodeToString(randomPart)
+
+	_, err = database.ExecuteNamedQuery(
+		`INSERT INTO meal_plan_calendar_tokens (user_id, token_hash, created_at)
+				VALUES (:user_id, :token_hash, NOW())
+				ON CONFLICT (user_id) DO UPDATE SET token_hash = excluded.token_hash,
+													created_at = excluded.created_at;`,
+		map[string]interface{}{"user_id": userId, "token_hash": hashCalendarToken(token)},
+	)
+	return
+}
+
+// RevokeCalendarToken revokes the calendar feed token of the user, so calendar apps lose access to the meal plan
+func RevokeCalendarToken(userId int) (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`DELETE FROM meal_plan_calendar_tokens WHERE user_id = :user_id;`,
+		map[string]interface{}{"user_id": userId},
+	)
+	return
+}
+
+// GetCalendarTokenOwner finds the user the calendar feed token was issued to
+func GetCalendarTokenOwner(token string) (userId int, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&userId,
+		`SELECT users.id
+				FROM meal_plan_calendar_tokens
+						 JOIN users ON users.id = meal_plan_calendar_tokens.user_id
+				WHERE token_hash = :token_hash
+				  AND users.deleted_at IS NULL;`,
+		map[string]interface{}{"token_hash": hashCalendarToken(token)},
+	)
+	return
+}
+
+func hashCalendarToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,213 @@
+package mealplans
+
+import (
+	"fmt"
+	"recipes-v2-server/database"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ParseDate parses a date in the YYYY-MM-DD format used by the meal plan endpoints
+func ParseDate(date string) (time.Time, error) {
+	return time.Parse(dateLayout, date)
+}
+
+// GetForPeriod gets the meal plan entries of the user between the given dates (both inclusive)
+func GetForPeriod(userId int, from, to time.Time) (entries []MealPlanEntry, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&entries,
+		`SELECT meal_plan_entries.id,
+					   planned_for,
+					   meal_slot,
+					   servings,
+					   recipe_name,
+					   image_url,
+					   COALESCE(calories, 0) AS calories,
+					   COALESCE(protein, 0)  AS protein
+				FROM meal_plan_entries
+						 JOIN recipes ON recipes.id = meal_plan_entries.recipe_id
+				WHERE user_id = :user_id
+				  AND planned_for BETWEEN :from AND :to
+				  AND recipes.deleted_at IS NULL
+				ORDER BY planned_for,
+						 ARRAY_POSITION(ARRAY ['BREAKFAST', 'LUNCH', 'DINNER', 'SNACK'], meal_slot);`,
+		map[string]interface{}{"user_id": userId, "from": from.Format(dateLayout), "to": to.Format(dateLayout)},
+	)
+	return
+}
+
+// GetAll gets every meal plan entry of the user, the earliest first
+func GetAll(userId int) (entries []MealPlanEntry, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&entries,
+		`SELECT meal_plan_entries.id,
+					   planned_for,
+					   meal_slot,
+					   servings,
+					   recipe_name,
+					   image_url,
+					   COALESCE(calories, 0) AS calories,
+					   COALESCE(protein, 0)  AS protein
+				FROM meal_plan_entries
+						 JOIN recipes ON recipes.id = meal_plan_entries.recipe_id
+				WHERE user_id = :user_id
+				  AND recipes.deleted_at IS NULL
+				ORDER BY planned_for,
+						 ARRAY_POSITION(ARRAY ['BREAKFAST', 'LUNCH', 'DINNER', 'SNACK'], meal_slot);`,
+		map[string]interface{}{"user_id": userId},
+	)
+	return
+}
+
+// AddEntry assigns a recipe to a day and meal slot in the user meal plan
+func AddEntry(request MealPlanEntryRequest) (entry MealPlanEntry, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&entry,
+		`WITH recipe AS (SELECT id, recipe_name, image_url, calories, protein
+						FROM recipes
+						WHERE recipe_name = :recipe_name AND status = 'APPROVED' AND deleted_at IS NULL),
+
+					 inserted_entry AS (INSERT INTO meal_plan_entries (user_id, recipe_id, planned_for, meal_slot, servings, created_at)
+						 SELECT :user_id, recipe.id, CAST(:planned_for AS DATE), :meal_slot, :servings, NOW()
+						 FROM recipe
+						 RETURNING *)
+
+				SELECT inserted_entry.id,
+					   planned_for,
+					   meal_slot,
+					   servings,
+					   recipe_name,
+					   image_url,
+					   COALESCE(calories, 0) AS calories,
+					   COALESCE(protein, 0)  AS protein
+				FROM inserted_entry
+						 JOIN recipe ON recipe.id = inserted_entry.recipe_id;`,
+		request,
+	)
+	return
+}
+
+// DeleteEntry removes an entry from the user meal plan
+func DeleteEntry(userId, id int) (err error) {
+	var deletedId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&deletedId,
+		`DELETE FROM meal_plan_entries WHERE id = :id AND user_id = :user_id RETURNING id;`,
+		map[string]interface{}{"id": id, "user_id": userId},
+	)
+	return
+}
+
+// CopyWeek copies the seven days starting from the source week start to the seven days starting from the target
+// week start. Returns the number of copied entries.
+func CopyWeek(userId int, fromWeekStart, toWeekStart time.Time) (copied int64, err error) {
+	result, err := database.ExecuteNamedQuery(
+		`INSERT INTO meal_plan_entries (user_id, recipe_id, planned_for, meal_slot, servings, created_at)
+				SELECT user_id,
+					   recipe_id,
+					   planned_for + (CAST(:to_week_start AS DATE) - CAST(:from_week_start AS DATE)),
+					   meal_slot,
+					   servings,
+					   NOW()
+				FROM meal_plan_entries
+				WHERE user_id = :user_id
+				  AND planned_for >= CAST(:from_week_start AS DATE)
+				  AND planned_for < CAST(:from_week_start AS DATE) + 7;`,
+		map[string]interface{}{
+			"user_id":         userId,
+			"from_week_start": fromWeekStart.Format(dateLayout),
+			"to_week_start":   toWeekStart.Format(dateLayout),
+		},
+	)
+	if err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
+
+// GetDailyNutrition sums the calories and protein of the planned recipes, multiplied by the planned servings,
+// for every day between the given dates (both inclusive) that has any entries
+func GetDailyNutrition(userId int, from, to time.Time) (nutrition []DailyNutrition, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&nutrition,
+		`SELECT planned_for,
+					   SUM(COALESCE(calories, 0) * servings) AS calories,
+					   SUM(COALESCE(protein, 0) * servings)  AS protein
+				FROM meal_plan_entries
+						 JOIN recipes ON recipes.id = meal_plan_entries.recipe_id
+				WHERE user_id = :user_id
+				  AND planned_for BETWEEN :from AND :to
+				  AND recipes.deleted_at IS NULL
+				GROUP BY planned_for
+				ORDER BY planned_for;`,
+		map[string]interface{}{"user_id": userId, "from": from.Format(dateLayout), "to": to.Format(dateLayout)},
+	)
+	return
+}
+
+// Calendar renders the user meal plan entries in the given period as an iCalendar (RFC 5545) feed
+func Calendar(userId int, from, to time.Time) (calendar string, err error) {
+	entries, err := GetForPeriod(userId, from, to)
+	if err != nil {
+		return
+	}
+
+	timestamp := time.Now().UTC().Format("20060102T150405Z")
+
+	var builder strings.Builder
+	builder.WriteString("BEGIN:VCALENDAR\r\n")
+	builder.WriteString("VERSION:2.0\r\n")
+	builder.WriteString("PRODID:-//recipes-v2-server//Meal Plan//EN\r\n")
+	builder.WriteString("CALSCALE:GREGORIAN\r\n")
+	builder.WriteString("X-WR-CALNAME:Meal Plan\r\n")
+
+	for _, entry := range entries {
+		summary := fmt.Sprintf("%s: %s (%d servings)", mealSlotTitle(entry.MealSlot), entry.RecipeName, entry.Servings)
+		description := fmt.Sprintf("%d kcal, %dg protein", entry.Calories*entry.Servings, entry.Protein*entry.Servings)
+
+		builder.WriteString("BEGIN:VEVENT\r\n")
+		builder.WriteString(fmt.Sprintf("UID:meal-plan-entry-%d@recipes-v2-server\r\n", entry.Id))
+		builder.WriteString("DTSTAMP:" + timestamp + "\r\n")
+		builder.WriteString("DTSTART;VALUE=DATE:" + entry.PlannedFor.Format("20060102") + "\r\n")
+		builder.WriteString("DTEND;VALUE=DATE:" + entry.PlannedFor.AddDate(0, 0, 1).Format("20060102") + "\r\n")
+		builder.WriteString(foldCalendarLine("SUMMARY:" + escapeCalendarText(summary)))
+		builder.WriteString(foldCalendarLine("DESCRIPTION:" + escapeCalendarText(description)))
+		builder.WriteString("END:VEVENT\r\n")
+	}
+
+	builder.WriteString("END:VCALENDAR\r\n")
+	return builder.String(), nil
+}
+
+func mealSlotTitle(mealSlot string) string {
+	return strings.ToUpper(mealSlot[:1]) + strings.ToLower(mealSlot[1:])
+}
+
+func escapeCalendarText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// foldCalendarLine splits content lines longer than 75 octets as required by the iCalendar specification
+func foldCalendarLine(line string) string {
+	const maxLineLength = 75
+
+	var builder strings.Builder
+	currentLength := 0
+
+	for _, character := range line {
+		characterLength := len(string(character))
+		if currentLength+characterLength > maxLineLength {
+			builder.WriteString("\r\n ")
+			currentLength = 1
+		}
+		builder.WriteRune(character)
+		currentLength += characterLength
+	}
+
+	builder.WriteString("\r\n")
+	return builder.String()
+}
@@ -0,0 +1,36 @@
+package mealplans
+
+import (
+	"recipes-v2-server/storage"
+	"time"
+)
+
+type MealPlanEntry struct {
+	Id         int               `json:"id" db:"id"`
+	PlannedFor time.Time         `json:"date" db:"planned_for"`
+	MealSlot   string            `json:"mealSlot" db:"meal_slot"`
+	Servings   int               `json:"servings" db:"servings"`
+	RecipeName string            `json:"recipeName" db:"recipe_name"`
+	ImageURL   storage.ObjectKey `json:"imageURL" db:"image_url"`
+	Calories   int               `json:"calories" db:"calories"`
+	Protein    int               `json:"protein" db:"protein"`
+}
+
+type MealPlanEntryRequest struct {
+	RecipeName string `json:"recipeName" db:"recipe_name" valid:"required"`
+	Date       string `json:"date" db:"planned_for" valid:"required"`
+	MealSlot   string `json:"mealSlot" db:"meal_slot" valid:"required,in(BREAKFAST|LUNCH|DINNER|SNACK)"`
+	Servings   int    `json:"servings" db:"servings" valid:"required,range(1|50)"`
+	UserId     int    `json:"-" db:"user_id"`
+}
+
+type CopyWeekRequest struct {
+	FromWeekStart string `json:"fromWeekStart" valid:"required"`
+	ToWeekStart   string `json:"toWeekStart" valid:"required"`
+}
+
+type DailyNutrition struct {
+	Date     time.Time `json:"date" db:"planned_for"`
+	Calories int       `json:"calories" db:"calories"`
+	Protein  int       `json:"protein" db:"protein"`
+}
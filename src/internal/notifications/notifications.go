@@ -105,6 +105,7 @@ func findNotificationReceivers(request NotificationRequest) (results pq.Int32Arr
 		"CREATED_COMMENT": findCreateCommentActionReceivers,
 		"EDITED_COMMENT":  findDeleteCommentActionReceivers,
 		"DELETED_COMMENT": findDeleteCommentActionReceivers,
+		"COOKED_RECIPE":   findCookedRecipeActionReceivers,
 	}
 
 	handlerFunc, found := notificationActionsReceiversMap[request.Action]
@@ -123,13 +124,15 @@ func findEditRecipeActionReceivers(request NotificationRequest) (results pq.Int3
                                     FROM users
                                              JOIN users_roles ON user_entity_id = users.id
                                     WHERE roles_id IN (1, 2)
-                                      AND id != :sender_id),
+                                      AND id != :sender_id
+                                      AND deleted_at IS NULL),
 
 				 resource_owner_that_is_not_the_sender AS (SELECT users.id
 														   FROM users
 																	JOIN recipes ON owner_id = users.id
 														   WHERE recipes.recipe_name = :location_name
-															 AND users.id != :sender_id)
+															 AND users.id != :sender_id
+															 AND users.deleted_at IS NULL)
 			
 			SELECT ARRAY(SELECT id
 						 FROM admin_and_moderator_groups
@@ -151,7 +154,8 @@ func findCreateRecipeActionReceivers(request NotificationRequest) (results pq.In
                             FROM users
                                 JOIN users_roles ON user_entity_id = users.id
                             WHERE roles_id = 1
-                                AND id != :sender_id) AS results;`,
+                                AND id != :sender_id
+                                AND deleted_at IS NULL) AS results;`,
 		request,
 	)
 	return
@@ -164,13 +168,15 @@ func findDeleteRecipeActionReceivers(request NotificationRequest) (results pq.In
                                     FROM users
                                              JOIN users_roles ON user_entity_id = users.id
                                     WHERE roles_id = 1
-                                      AND id != :sender_id),
+                                      AND id != :sender_id
+                                      AND deleted_at IS NULL),
 
 				 resource_owner_that_is_not_the_sender AS (SELECT users.id
 														   FROM users
 																	JOIN recipes ON owner_id = users.id
 														   WHERE recipes.recipe_name = :location_name
-															 AND users.id != :sender_id)
+															 AND users.id != :sender_id
+															 AND users.deleted_at IS NULL)
 			
 			SELECT ARRAY(SELECT id
 						 FROM admin_groups
@@ -192,19 +198,24 @@ func findCreateCommentActionReceivers(request NotificationRequest) (results pq.I
                                     FROM users
                                              JOIN users_roles ON user_entity_id = users.id
                                     WHERE roles_id IN (1, 2)
-                                      AND id != :sender_id),
+                                      AND id != :sender_id
+                                      AND deleted_at IS NULL),
 
 					 resource_owner_that_is_not_the_sender AS (SELECT users.id
 															   FROM users
 																		JOIN recipes ON owner_id = users.id
 															   WHERE recipes.recipe_name = :location_name
-																 AND users.id != :sender_id),
+																 AND users.id != :sender_id
+																 AND users.deleted_at IS NULL),
 				
 					 users_involved_into_the_conversation AS (SELECT comments.owner_id
 															  FROM comments
 																	   JOIN recipes ON comments.target_recipe_id = recipes.id
+																	   JOIN users ON comments.owner_id = users.id
 															  WHERE recipe_name = :location_name
-																AND comments.owner_id != :sender_id)
+																AND comments.owner_id != :sender_id
+																AND comments.deleted_at IS NULL
+																AND users.deleted_at IS NULL)
 				
 				SELECT ARRAY(SELECT id
 							 FROM admin_and_moderator_groups
@@ -231,17 +242,21 @@ func findDeleteCommentActionReceivers(request NotificationRequest) (results pq.I
                                     FROM users
                                              JOIN users_roles ON user_entity_id = users.id
                                     WHERE roles_id IN (1, 2)
-                                      AND id != :sender_id),
+                                      AND id != :sender_id
+                                      AND deleted_at IS NULL),
 
 					 resource_owner_that_is_not_the_sender AS (SELECT users.id
 															   FROM users
 																		JOIN recipes ON owner_id = users.id
 															   WHERE recipes.recipe_name = :location_name
-																 AND users.id != :sender_id),
+																 AND users.id != :sender_id
+																 AND users.deleted_at IS NULL),
 				
 					 comment_owner_that_is_not_the_sender AS (SELECT users.id
 															  FROM users
-															  WHERE username = :owner_name AND users.id != :sender_id)
+															  WHERE username = :owner_name
+															    AND users.id != :sender_id
+															    AND users.deleted_at IS NULL)
 				
 				SELECT ARRAY(SELECT id
 							 FROM admin_and_moderator_groups
@@ -260,3 +275,17 @@ func findDeleteCommentActionReceivers(request NotificationRequest) (results pq.I
 	)
 	return
 }
+
+func findCookedRecipeActionReceivers(request NotificationRequest) (results pq.Int32Array, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&results,
+		`SELECT ARRAY(SELECT users.id
+                            FROM users
+                                JOIN recipes ON owner_id = users.id
+                            WHERE recipes.recipe_name = :location_name
+                                AND users.id != :sender_id
+                                AND users.deleted_at IS NULL) AS results;`,
+		request,
+	)
+	return
+}
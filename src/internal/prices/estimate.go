@@ -0,0 +1,182 @@
+package prices
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type measurement struct {
+	factor    float64
+	dimension string
+}
+
+// units converts the measurement units used in the products to grams, millilitres or pieces
+var units = map[string]measurement{
+	"г": {1, "g"}, "гр": {1, "g"}, "грам": {1, "g"}, "грама": {1, "g"}, "g": {1, "g"}, "gr": {1, "g"},
+	"кг": {1000, "g"}, "килограм": {1000, "g"}, "килограма": {1000, "g"}, "kg": {1000, "g"},
+	"мл": {1, "ml"}, "ml": {1, "ml"}, "л": {1000, "ml"}, "литър": {1000, "ml"}, "литра": {1000, "ml"}, "l": {1000, "ml"},
+	"ч.л": {5, "ml"}, "чаена": {5, "ml"}, "чаени": {5, "ml"}, "tsp": {5, "ml"},
+	"с.л": {15, "ml"}, "супена": {15, "ml"}, "супени": {15, "ml"}, "tbsp": {15, "ml"},
+	"чаша": {240, "ml"}, "чаши": {240, "ml"}, "cup": {240, "ml"}, "cups": {240, "ml"},
+	"бр": {1, "pcs"}, "броя": {1, "pcs"}, "брой": {1, "pcs"}, "pcs": {1, "pcs"}, "pc": {1, "pcs"},
+}
+
+// priceUnits are the units of the price table in the dimensions of the product units
+var priceUnits = map[string]measurement{
+	"kg":  {1000, "g"},
+	"l":   {1000, "ml"},
+	"pcs": {1, "pcs"},
+}
+
+// quantityPattern matches the amount of a product - a number, a fraction or a whole number with a fraction - and the
+// unit after it
+var quantityPattern = regexp.MustCompile(`(\d+(?:[.,]\d+)?)(?:\s+(\d+)\s*/\s*(\d+)|\s*/\s*(\d+))?\s*([^\s\d]*)`)
+
+// Calculate estimates the total and per serving cost of the products of a recipe from the price table. The
+// products that have no price, no quantity or a quantity that can not be converted to the unit of their price are
+// listed as unpriced and make the estimate incomplete. Prices in another currency than the first priced product are
+// also not counted. The per serving cost is left out when the servings are unknown, as for the recipes created before
+// the servings were recorded.
+func Calculate(products []string, servings int, prices []IngredientPrice) (estimate Estimate) {
+	estimate.UnpricedIngredients = []string{}
+
+	for _, product := range products {
+		cost, currency, isPriced := productCost(product, prices)
+		if isPriced && estimate.Currency == "" {
+			estimate.Currency = currency
+		}
+		if !isPriced || currency != estimate.Currency {
+			estimate.UnpricedIngredients = append(estimate.UnpricedIngredients, product)
+			continue
+		}
+		estimate.TotalCost += cost
+	}
+
+	estimate.IsComplete = len(estimate.UnpricedIngredients) == 0 && len(products) > 0
+	if servings > 0 {
+		estimate.PerServingCost = roundToCents(estimate.TotalCost / float64(servings))
+	}
+	estimate.TotalCost = roundToCents(estimate.TotalCost)
+	return
+}
+
+func productCost(product string, prices []IngredientPrice) (cost float64, currency string, isPriced bool) {
+	price, found := matchPrice(product, prices)
+	if !found {
+		return
+	}
+
+	amount, dimension, found := parseQuantity(product)
+	priceUnit := priceUnits[price.Unit]
+	if !found || dimension != priceUnit.dimension {
+		return
+	}
+	return amount / priceUnit.factor * price.PricePerUnit, price.Currency, true
+}
+
+// matchPrice finds the price of the ingredient with the most words that all appear in the product
+func matchPrice(product string, prices []IngredientPrice) (match IngredientPrice, found bool) {
+	ingredients := make([]string, len(prices))
+	for index, price := range prices {
+		ingredients[index] = price.Ingredient
+	}
+
+	index, found := MatchIngredient(product, ingredients)
+	if found {
+		match = prices[index]
+	}
+	return
+}
+
+// MatchIngredient finds the index of the ingredient with the most words that all appear in the product. Words match
+// when one is the beginning of the other, so different forms of the same word are still matched.
+func MatchIngredient(product string, ingredients []string) (match int, found bool) {
+	productWords := ingredientWords(product)
+
+	bestWordsCount := 0
+	for index, ingredient := range ingredients {
+		words := ingredientWords(ingredient)
+		if len(words) <= bestWordsCount || !containsAll(productWords, words) {
+			continue
+		}
+		match, found, bestWordsCount = index, true, len(words)
+	}
+	return
+}
+
+func containsAll(words, wanted []string) bool {
+	for _, wantedWord := range wanted {
+		contained := false
+		for _, word := range words {
+			if wordsMatch(word, wantedWord) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			return false
+		}
+	}
+	return true
+}
+
+func wordsMatch(first, second string) bool {
+	if first == second {
+		return true
+	}
+
+	shorter, longer := first, second
+	if len([]rune(shorter)) > len([]rune(longer)) {
+		shorter, longer = longer, shorter
+	}
+	return len([]rune(shorter)) >= 4 && strings.HasPrefix(longer, shorter)
+}
+
+// parseQuantity reads the first amount of the product with its unit, converted to grams, millilitres or pieces.
+// Amounts without a known unit are counted as pieces.
+func parseQuantity(product string) (amount float64, dimension string, found bool) {
+	matches := quantityPattern.FindStringSubmatch(strings.ToLower(product))
+	if matches == nil {
+		return
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(matches[1], ",", ".", 1), 64)
+	if err != nil {
+		return
+	}
+	switch {
+	case matches[2] != "":
+		numerator, _ := strconv.ParseFloat(matches[2], 64)
+		denominator, _ := strconv.ParseFloat(matches[3], 64)
+		if denominator == 0 {
+			return
+		}
+		amount += numerator / denominator
+	case matches[4] != "":
+		denominator, _ := strconv.ParseFloat(matches[4], 64)
+		if denominator == 0 {
+			return
+		}
+		amount /= denominator
+	}
+
+	unit, isKnown := units[strings.TrimRight(matches[5], ".,;:)")]
+	if !isKnown {
+		return amount, "pcs", true
+	}
+	return amount * unit.factor, unit.dimension, true
+}
+
+// ingredientWords lowercases the text and splits it to words of letters, dropping the amounts and punctuation
+func ingredientWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(character rune) bool {
+		return !unicode.IsLetter(character)
+	})
+}
+
+func roundToCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
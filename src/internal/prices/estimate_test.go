@@ -0,0 +1,135 @@
+package prices
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testPrices = []IngredientPrice{
+	{Ingredient: "брашно", Unit: "kg", PricePerUnit: 2, Currency: "BGN"},
+	{Ingredient: "мляко", Unit: "l", PricePerUnit: 3, Currency: "BGN"},
+	{Ingredient: "кисело мляко", Unit: "kg", PricePerUnit: 4, Currency: "BGN"},
+	{Ingredient: "яйца", Unit: "pcs", PricePerUnit: 0.5, Currency: "BGN"},
+	{Ingredient: "шафран", Unit: "kg", PricePerUnit: 5000, Currency: "EUR"},
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name     string
+		products []string
+		servings int
+		want     Estimate
+	}{
+		{
+			name:     "all products priced",
+			products: []string{"500 г брашно", "1 л мляко", "2 яйца"},
+			servings: 4,
+			want:     Estimate{TotalCost: 5, PerServingCost: 1.25, Currency: "BGN", IsComplete: true, UnpricedIngredients: []string{}},
+		},
+		{
+			name:     "unknown servings leave out the per serving cost",
+			products: []string{"250 гр брашно"},
+			servings: 0,
+			want:     Estimate{TotalCost: 0.5, Currency: "BGN", IsComplete: true, UnpricedIngredients: []string{}},
+		},
+		{
+			name:     "product without a price",
+			products: []string{"1 кг брашно", "200 г сирене"},
+			servings: 2,
+			want:     Estimate{TotalCost: 2, PerServingCost: 1, Currency: "BGN", IsComplete: false, UnpricedIngredients: []string{"200 г сирене"}},
+		},
+		{
+			name:     "product without a quantity",
+			products: []string{"мляко"},
+			servings: 1,
+			want:     Estimate{UnpricedIngredients: []string{"мляко"}},
+		},
+		{
+			name:     "quantity in another dimension than the price",
+			products: []string{"2 бр мляко"},
+			servings: 1,
+			want:     Estimate{UnpricedIngredients: []string{"2 бр мляко"}},
+		},
+		{
+			name:     "price in another currency",
+			products: []string{"1 кг брашно", "1 г шафран"},
+			servings: 1,
+			want:     Estimate{TotalCost: 2, PerServingCost: 2, Currency: "BGN", UnpricedIngredients: []string{"1 г шафран"}},
+		},
+		{
+			name:     "no products",
+			products: []string{},
+			servings: 2,
+			want:     Estimate{UnpricedIngredients: []string{}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Calculate(test.products, test.servings, testPrices)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Calculate(%q, %d) = %+v, want %+v", test.products, test.servings, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		product       string
+		wantAmount    float64
+		wantDimension string
+		wantFound     bool
+	}{
+		{product: "500 г брашно", wantAmount: 500, wantDimension: "g", wantFound: true},
+		{product: "1,5 кг картофи", wantAmount: 1500, wantDimension: "g", wantFound: true},
+		{product: "2 ч.л. захар", wantAmount: 10, wantDimension: "ml", wantFound: true},
+		{product: "1 с.л. олио", wantAmount: 15, wantDimension: "ml", wantFound: true},
+		{product: "1/2 чаша мляко", wantAmount: 120, wantDimension: "ml", wantFound: true},
+		{product: "1 1/2 чаши мляко", wantAmount: 360, wantDimension: "ml", wantFound: true},
+		{product: "2 1/0 чаши мляко", wantFound: false},
+		{product: "0.5 l milk", wantAmount: 500, wantDimension: "ml", wantFound: true},
+		{product: "3 яйца", wantAmount: 3, wantDimension: "pcs", wantFound: true},
+		{product: "Брашно (200 g)", wantAmount: 200, wantDimension: "g", wantFound: true},
+		{product: "1/0 чаша мляко", wantFound: false},
+		{product: "сол на вкус", wantFound: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.product, func(t *testing.T) {
+			amount, dimension, found := parseQuantity(test.product)
+			if found != test.wantFound || found && (amount != test.wantAmount || dimension != test.wantDimension) {
+				t.Errorf("parseQuantity(%q) = (%v, %q, %v), want (%v, %q, %v)",
+					test.product, amount, dimension, found, test.wantAmount, test.wantDimension, test.wantFound)
+			}
+		})
+	}
+}
+
+func TestMatchIngredient(t *testing.T) {
+	ingredients := []string{"мляко", "кисело мляко", "брашно", "яйца", "sugar"}
+
+	tests := []struct {
+		product   string
+		wantMatch int
+		wantFound bool
+	}{
+		{product: "1 л прясно мляко", wantMatch: 0, wantFound: true},
+		{product: "400 г кисело мляко", wantMatch: 1, wantFound: true},
+		{product: "300 г пшеничено брашно", wantMatch: 2, wantFound: true},
+		{product: "2 яйцата", wantMatch: 3, wantFound: true},
+		{product: "100 g Sugar", wantMatch: 4, wantFound: true},
+		{product: "100 г масло", wantFound: false},
+		{product: "1 бр яйце", wantFound: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.product, func(t *testing.T) {
+			match, found := MatchIngredient(test.product, ingredients)
+			if match != test.wantMatch || found != test.wantFound {
+				t.Errorf("MatchIngredient(%q) = (%d, %v), want (%d, %v)",
+					test.product, match, found, test.wantMatch, test.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package prices
+
+import (
+	"recipes-v2-server/database"
+	"strings"
+)
+
+// GetAll gets the ingredient price table ordered by ingredient
+func GetAll() (prices []IngredientPrice, err error) {
+	err = database.GetMultipleRecords(
+		&prices,
+		`SELECT id, ingredient, unit, price_per_unit, currency, updated_at
+				FROM ingredient_prices
+				ORDER BY ingredient;`,
+	)
+	return
+}
+
+// Save adds the price of an ingredient or replaces it when the ingredient already has one
+func Save(request IngredientPriceRequest) (price IngredientPrice, err error) {
+	request.Ingredient = strings.Join(ingredientWords(request.Ingredient), " ")
+	request.Currency = strings.ToUpper(request.Currency)
+
+	err = database.GetSingleRecordNamedQuery(
+		&price,
+		`INSERT INTO ingredient_prices (ingredient, unit, price_per_unit, currency, updated_at)
+				VALUES (:ingredient, :unit, :price_per_unit, :currency, NOW())
+				ON CONFLICT (ingredient) DO UPDATE SET unit           = excluded.unit,
+													   price_per_unit = excluded.price_per_unit,
+													   currency       = excluded.currency,
+													   updated_at     = excluded.updated_at
+				RETURNING id, ingredient, unit, price_per_unit, currency, updated_at;`,
+		request,
+	)
+	return
+}
+
+// Delete removes the price of an ingredient
+func Delete(id int) (err error) {
+	var deletedId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&deletedId,
+		`DELETE FROM ingredient_prices WHERE id = :id RETURNING id;`,
+		map[string]interface{}{"id": id},
+	)
+	return
+}
@@ -0,0 +1,30 @@
+package prices
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+type IngredientPrice struct {
+	Id           int       `json:"id" db:"id"`
+	Ingredient   string    `json:"ingredient" db:"ingredient"`
+	Unit         string    `json:"unit" db:"unit"`
+	PricePerUnit float64   `json:"pricePerUnit" db:"price_per_unit"`
+	Currency     string    `json:"currency" db:"currency"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type IngredientPriceRequest struct {
+	Ingredient   string  `json:"ingredient" db:"ingredient" valid:"required,minstringlength(2)"`
+	Unit         string  `json:"unit" db:"unit" valid:"required,in(kg|l|pcs)"`
+	PricePerUnit float64 `json:"pricePerUnit" db:"price_per_unit" valid:"required"`
+	Currency     string  `json:"currency" db:"currency" valid:"required,stringlength(3|3)"`
+}
+
+type Estimate struct {
+	TotalCost           float64        `json:"totalCost" db:"total_cost"`
+	PerServingCost      float64        `json:"perServingCost,omitempty" db:"per_serving_cost"`
+	Currency            string         `json:"currency" db:"currency"`
+	IsComplete          bool           `json:"isComplete" db:"is_complete"`
+	UnpricedIngredients pq.StringArray `json:"unpricedIngredients" db:"unpriced_ingredients"`
+}
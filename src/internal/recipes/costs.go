@@ -0,0 +1,191 @@
+package recipes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/prices"
+	"recipes-v2-server/utils"
+	"strings"
+	"time"
+)
+
+// maxCostFilter keeps the recipes estimated to cost up to the :max_cost parameter in the :currency parameter, or all
+// of them when it is not positive. Only complete estimates take part, as a recipe with unpriced ingredients costs more
+// than its estimate, and estimates in another currency are not comparable to the limit.
+const maxCostFilter = `(CAST(:max_cost AS NUMERIC) <= 0
+	OR recipes.id IN (SELECT recipe_id
+					  FROM recipe_cost_estimates
+					  WHERE is_complete
+						AND currency = :currency
+						AND total_cost <= CAST(:max_cost AS NUMERIC)))`
+
+type costCandidate struct {
+	Id       int             `db:"id"`
+	Products json.RawMessage `db:"products"`
+	Servings int             `db:"servings"`
+}
+
+// RefreshCostEstimates recalculates the cost estimates of all recipes from the current ingredient prices
+func RefreshCostEstimates() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.JobTimeout)
+	defer cancel()
+
+	var candidates []costCandidate
+
+	err = database.GetMultipleRecordsContext(
+		ctx,
+		&candidates,
+		`SELECT id, products, servings FROM recipes WHERE deleted_at IS NULL;`,
+	)
+	if err != nil {
+		return
+	}
+	return saveCostEstimates(ctx, candidates)
+}
+
+// estimateCost recalculates the cost estimate of a recipe after it was created or edited. A failure only leaves the
+// estimate outdated until the prices change, so it is logged instead of failing the request.
+func estimateCost(recipeName string) {
+	if err := refreshCostEstimate(recipeName); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Warnf("Error on estimating the cost of recipe %s", recipeName)
+	}
+}
+
+func refreshCostEstimate(recipeName string) (err error) {
+	var candidates []costCandidate
+
+	err = database.GetMultipleRecordsNamedQuery(
+		&candidates,
+		`SELECT id, products, servings FROM recipes WHERE recipe_name = :recipe_name;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return saveCostEstimates(ctx, candidates)
+}
+
+func saveCostEstimates(ctx context.Context, candidates []costCandidate) (err error) {
+	priceList, err := prices.GetAll()
+	if err != nil {
+		return
+	}
+
+	var recipeIds pq.Int32Array
+	var totalCosts, perServingCosts pq.Float64Array
+	var currencies, unpricedIngredients pq.StringArray
+	var areComplete pq.BoolArray
+
+	for _, candidate := range candidates {
+		estimate := prices.Calculate(productLines(candidate.Products), candidate.Servings, priceList)
+
+		// the unpriced ingredients of every recipe are packed into a single array element to be unnested together
+		unpriced, _ := json.Marshal(estimate.UnpricedIngredients)
+
+		recipeIds = append(recipeIds, int32(candidate.Id))
+		totalCosts = append(totalCosts, estimate.TotalCost)
+		perServingCosts = append(perServingCosts, estimate.PerServingCost)
+		currencies = append(currencies, estimate.Currency)
+		areComplete = append(areComplete, estimate.IsComplete)
+		unpricedIngredients = append(unpricedIngredients, string(unpriced))
+	}
+
+	_, err = database.ExecuteNamedQueryContext(
+		ctx,
+		`INSERT INTO recipe_cost_estimates (recipe_id, total_cost, per_serving_cost, currency, is_complete,
+											unpriced_ingredients, computed_at)
+				SELECT recipe_id,
+					   total_cost,
+					   per_serving_cost,
+					   currency,
+					   is_complete,
+					   ARRAY(SELECT JSON_ARRAY_ELEMENTS_TEXT(CAST(unpriced AS JSON))),
+					   NOW()
+				FROM UNNEST(CAST(:recipe_ids AS INT[]),
+							CAST(:total_costs AS NUMERIC[]),
+							CAST(:per_serving_costs AS NUMERIC[]),
+							CAST(:currencies AS TEXT[]),
+							CAST(:are_complete AS BOOLEAN[]),
+							CAST(:unpriced_ingredients AS TEXT[]))
+						 AS estimates(recipe_id, total_cost, per_serving_cost, currency, is_complete, unpriced)
+				ON CONFLICT (recipe_id) DO UPDATE SET total_cost           = excluded.total_cost,
+													  per_serving_cost     = excluded.per_serving_cost,
+													  currency             = excluded.currency,
+													  is_complete          = excluded.is_complete,
+													  unpriced_ingredients = excluded.unpriced_ingredients,
+													  computed_at          = excluded.computed_at;`,
+		map[string]interface{}{
+			"recipe_ids":           recipeIds,
+			"total_costs":          totalCosts,
+			"per_serving_costs":    perServingCosts,
+			"currencies":           currencies,
+			"are_complete":         areComplete,
+			"unpriced_ingredients": unpricedIngredients,
+		},
+	)
+	return
+}
+
+// getCostEstimate gets the stored cost estimate of the recipe. Returns nil for recipes that are not estimated yet.
+func getCostEstimate(recipeName string) (estimate *prices.Estimate, err error) {
+	var result prices.Estimate
+
+	err = database.GetSingleRecordNamedQuery(
+		&result,
+		`SELECT total_cost,
+					   per_serving_cost,
+					   currency,
+					   is_complete,
+					   unpriced_ingredients
+				FROM recipe_cost_estimates
+						 JOIN recipes ON recipes.id = recipe_cost_estimates.recipe_id
+				WHERE recipe_name = :recipe_name;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return
+	}
+	return &result, nil
+}
+
+// productLines turns the products of a recipe to text lines with their amounts. Products stored as objects have
+// their quantity and unit put in front of their name.
+func productLines(products json.RawMessage) (lines []string) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(products, &items); err != nil {
+		return
+	}
+
+	for _, item := range items {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(item, &fields); err != nil {
+			lines = append(lines, productText(item))
+			continue
+		}
+
+		var parts []string
+		for _, key := range []string{"quantity", "amount", "unit"} {
+			if value, ok := fields[key]; ok && value != nil {
+				parts = append(parts, fmt.Sprint(value))
+			}
+		}
+		lines = append(lines, strings.Join(append(parts, productText(item)), " "))
+	}
+	return
+}
@@ -0,0 +1,128 @@
+package recipes
+
+import (
+	"encoding/json"
+	"recipes-v2-server/database"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// duplicateWarningScore is the similarity from which the submitter is warned about a likely duplicate
+	duplicateWarningScore = 0.6
+	// duplicateFlagScore is the similarity from which the submission is flagged for the moderators
+	duplicateFlagScore   = 0.85
+	maxDuplicateWarnings = 5
+)
+
+// NormalizeName lowercases the recipe name and strips everything except letters, digits and single spaces
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(character rune) bool {
+		return !unicode.IsLetter(character) && !unicode.IsDigit(character)
+	})
+	return strings.Join(words, " ")
+}
+
+// NameSimilarity compares two recipe names with the Sørensen–Dice coefficient of their normalized character
+// bigrams, so small spelling differences and reordered words still score high
+func NameSimilarity(first, second string) float64 {
+	firstBigrams := bigrams(NormalizeName(first))
+	secondBigrams := bigrams(NormalizeName(second))
+	if len(firstBigrams) == 0 || len(secondBigrams) == 0 {
+		return 0
+	}
+
+	common := 0
+	for bigram, count := range firstBigrams {
+		common += min(count, secondBigrams[bigram])
+	}
+
+	total := 0
+	for _, count := range firstBigrams {
+		total += count
+	}
+	for _, count := range secondBigrams {
+		total += count
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+func bigrams(text string) map[string]int {
+	result := map[string]int{}
+	for _, word := range strings.Fields(text) {
+		characters := []rune(word)
+		for index := 0; index < len(characters)-1; index++ {
+			result[string(characters[index:index+2])]++
+		}
+	}
+	return result
+}
+
+// FindDuplicates compares the recipe with every other approved, scheduled or pending recipe by normalized name and
+// ingredient set and returns the likely duplicates, most similar first
+func FindDuplicates(recipeName string, products json.RawMessage) (duplicates []DuplicateCandidate, err error) {
+	var existingRecipes []similarityCandidate
+
+	err = database.GetMultipleRecordsNamedQuery(
+		&existingRecipes,
+		`SELECT id, recipe_name, products
+				FROM recipes
+				WHERE status IN ('APPROVED', 'SCHEDULED', 'PENDING')
+				  AND deleted_at IS NULL
+				  AND recipe_name != :recipe_name;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		return
+	}
+
+	ingredients := IngredientSet(products)
+
+	for _, existingRecipe := range existingRecipes {
+		score := 0.4*NameSimilarity(recipeName, existingRecipe.RecipeName) +
+			0.6*Jaccard(ingredients, IngredientSet(existingRecipe.Products))
+
+		if score >= duplicateWarningScore {
+			duplicates = append(duplicates, DuplicateCandidate{RecipeName: existingRecipe.RecipeName, Score: score})
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Score > duplicates[j].Score })
+	if len(duplicates) > maxDuplicateWarnings {
+		duplicates = duplicates[:maxDuplicateWarnings]
+	}
+	return
+}
+
+// flagIfDuplicate marks the recipe as a suspected duplicate for the moderators when its best match is similar enough
+func flagIfDuplicate(recipeName string, duplicates []DuplicateCandidate) (err error) {
+	if len(duplicates) == 0 || duplicates[0].Score < duplicateFlagScore {
+		return
+	}
+
+	_, err = database.ExecuteNamedQuery(
+		`UPDATE recipes
+				SET suspected_duplicate_of_id = (SELECT id FROM recipes WHERE recipe_name = :duplicate_name),
+					duplicate_score           = :score
+				WHERE recipe_name = :recipe_name;`,
+		map[string]interface{}{"recipe_name": recipeName, "duplicate_name": duplicates[0].RecipeName, "score": duplicates[0].Score},
+	)
+	return
+}
+
+// GetDuplicatesForModeration finds the likely duplicates of the recipe with the given id for the moderation queue
+func GetDuplicatesForModeration(id int) (duplicates []DuplicateCandidate, err error) {
+	var recipe RecipeData
+
+	err = database.GetSingleRecordNamedQuery(
+		&recipe,
+		`SELECT recipe_name, products FROM recipes WHERE id = :id AND deleted_at IS NULL;`,
+		map[string]interface{}{"id": id},
+	)
+	if err != nil {
+		return
+	}
+
+	return FindDuplicates(recipe.RecipeName, recipe.Products)
+}
@@ -0,0 +1,279 @@
+package recipes
+
+import (
+	"errors"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/storage"
+	"recipes-v2-server/utils"
+)
+
+// GetImages gets the gallery of the recipe ordered by position. The cover image is also the image_url of the recipe.
+func GetImages(recipeName string) (recipeImages []RecipeImage, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&recipeImages,
+		`SELECT recipe_images.id, recipe_images.image_url, alt_text, position, is_cover
+				FROM recipe_images
+						 JOIN recipes ON recipes.id = recipe_images.recipe_id
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL
+				ORDER BY position;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	return
+}
+
+// AddImage uploads a new image at the end of the recipe gallery
+func AddImage(recipeName string, content io.Reader, altText string) (recipeImage RecipeImage, err error) {
+	variants, err := images.Upload(content, images.RecipeImage)
+	if err != nil {
+		return
+	}
+
+	err = database.GetSingleRecordNamedQuery(
+		&recipeImage,
+		`INSERT INTO recipe_images (recipe_id, image_url, alt_text, position, is_cover, created_at)
+				SELECT recipes.id,
+					   :image_url,
+					   :alt_text,
+					   (SELECT COALESCE(MAX(position), 0) + 1 FROM recipe_images WHERE recipe_id = recipes.id),
+					   FALSE,
+					   NOW()
+				FROM recipes
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL
+				RETURNING id, image_url, alt_text, position, is_cover;`,
+		map[string]interface{}{"recipe_name": recipeName, "image_url": variants.Full, "alt_text": altText},
+	)
+	if err != nil {
+		deleteReplacedImages([]string{string(variants.Full)})
+	}
+	return
+}
+
+// EditImage changes the alt text of a gallery image and optionally makes it the cover image of the recipe. The cover
+// can only be moved to another image, so a recipe always has exactly one.
+func EditImage(recipeName string, id int, data RecipeImageEditRequest) (err error) {
+	var editedId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&editedId,
+		`WITH edited AS (UPDATE recipe_images
+							 SET alt_text = :alt_text,
+								 is_cover = is_cover OR :is_cover
+							 WHERE id = :id
+							   AND recipe_id = (SELECT id FROM recipes WHERE recipe_name = :recipe_name)
+							 RETURNING id, recipe_id, image_url),
+					 previous_cover AS (UPDATE recipe_images
+										 SET is_cover = FALSE
+										 FROM edited
+										 WHERE recipe_images.recipe_id = edited.recipe_id
+										   AND recipe_images.id != edited.id
+										   AND CAST(:is_cover AS BOOLEAN)),
+					 new_cover AS (UPDATE recipes
+									SET image_url = edited.image_url
+									FROM edited
+									WHERE recipes.id = edited.recipe_id
+									  AND CAST(:is_cover AS BOOLEAN))
+
+				SELECT id FROM edited;`,
+		map[string]interface{}{"recipe_name": recipeName, "id": id, "alt_text": data.AltText, "is_cover": data.IsCover},
+	)
+	return
+}
+
+// ReorderImages sets the position of the gallery images to their index in the given image ids list
+func ReorderImages(recipeName string, imageIds []int) (err error) {
+	ids := make(pq.Int32Array, 0, len(imageIds))
+	for _, id := range imageIds {
+		ids = append(ids, int32(id))
+	}
+
+	_, err = database.ExecuteNamedQuery(
+		`UPDATE recipe_images
+				SET position = ordered_images.position
+				FROM UNNEST(CAST(:image_ids AS INT[])) WITH ORDINALITY AS ordered_images(id, position)
+				WHERE recipe_images.id = ordered_images.id
+				  AND recipe_images.recipe_id = (SELECT id FROM recipes WHERE recipe_name = :recipe_name);`,
+		map[string]interface{}{"recipe_name": recipeName, "image_ids": ids},
+	)
+	return
+}
+
+// DeleteImage removes an image from the recipe gallery. When the cover image is removed the next image in the
+// gallery becomes the cover. The last image of a recipe can not be removed.
+func DeleteImage(recipeName string, id int) (err error) {
+	var imageKey string
+
+	err = database.GetSingleRecordNamedQuery(
+		&imageKey,
+		`WITH deleted AS (DELETE FROM recipe_images
+							  WHERE id = :id
+								AND recipe_id = (SELECT id FROM recipes WHERE recipe_name = :recipe_name)
+								AND EXISTS(SELECT 1
+										   FROM recipe_images AS other
+										   WHERE other.recipe_id = recipe_images.recipe_id
+											 AND other.id != recipe_images.id)
+							  RETURNING recipe_id, image_url, is_cover),
+					 next_cover AS (SELECT recipe_images.id, recipe_images.recipe_id, recipe_images.image_url
+									FROM recipe_images
+											 JOIN deleted ON deleted.recipe_id = recipe_images.recipe_id
+									WHERE recipe_images.id != :id
+									  AND deleted.is_cover
+									ORDER BY position
+									LIMIT 1),
+					 promoted AS (UPDATE recipe_images SET is_cover = TRUE FROM next_cover WHERE recipe_images.id = next_cover.id),
+					 new_cover AS (UPDATE recipes SET image_url = next_cover.image_url FROM next_cover WHERE recipes.id = next_cover.recipe_id)
+
+				SELECT image_url FROM deleted;`,
+		map[string]interface{}{"recipe_name": recipeName, "id": id},
+	)
+	if err != nil {
+		return
+	}
+
+	deleteReplacedImages([]string{imageKey})
+	return
+}
+
+// SetStepImage uploads a photo for the step with the given number, replacing its previous photo. Step numbers start
+// from 1.
+func SetStepImage(recipeName string, stepNumber int, content io.Reader) (variants images.Variants, err error) {
+	steps, err := getStepsForImageChange(recipeName, stepNumber)
+	if err != nil {
+		return
+	}
+
+	variants, err = images.Upload(content, images.StepImage)
+	if err != nil {
+		return
+	}
+
+	oldImageKey := steps[stepNumber-1].ImageURL
+	steps[stepNumber-1].ImageURL = variants.Full
+
+	err = updateSteps(recipeName, steps)
+	if err != nil {
+		deleteReplacedImages([]string{string(variants.Full)})
+		return
+	}
+
+	deleteReplacedImages([]string{string(oldImageKey)})
+	return
+}
+
+// DeleteStepImage removes the photo of the step with the given number. Step numbers start from 1.
+func DeleteStepImage(recipeName string, stepNumber int) (err error) {
+	steps, err := getStepsForImageChange(recipeName, stepNumber)
+	if err != nil {
+		return
+	}
+
+	oldImageKey := steps[stepNumber-1].ImageURL
+	steps[stepNumber-1].ImageURL = ""
+
+	err = updateSteps(recipeName, steps)
+	if err != nil {
+		return
+	}
+
+	deleteReplacedImages([]string{string(oldImageKey)})
+	return
+}
+
+func getStepsForImageChange(recipeName string, stepNumber int) (steps Steps, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&steps,
+		`SELECT steps FROM recipes WHERE recipe_name = :recipe_name AND deleted_at IS NULL;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		return
+	}
+
+	if stepNumber < 1 || stepNumber > len(steps) {
+		err = &StepOutOfRangeError{StepNumber: stepNumber, StepsCount: len(steps)}
+	}
+	return
+}
+
+func updateSteps(recipeName string, steps Steps) (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`UPDATE recipes SET steps = :steps WHERE recipe_name = :recipe_name;`,
+		map[string]interface{}{"recipe_name": recipeName, "steps": steps},
+	)
+	return
+}
+
+// syncCoverImage keeps the cover of the recipe gallery in sync with the image_url of the recipe, adding the gallery
+// cover for recipes that have no images yet
+func syncCoverImage(recipeName string) (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`WITH recipe AS (SELECT id, image_url FROM recipes WHERE recipe_name = :recipe_name),
+					 updated AS (UPDATE recipe_images
+								 SET image_url = recipe.image_url
+								 FROM recipe
+								 WHERE recipe_images.recipe_id = recipe.id
+								   AND recipe_images.is_cover
+								 RETURNING recipe_images.id)
+
+				INSERT
+				INTO recipe_images (recipe_id, image_url, alt_text, position, is_cover, created_at)
+				SELECT recipe.id, recipe.image_url, '', 1, TRUE, NOW()
+				FROM recipe
+				WHERE COALESCE(recipe.image_url, '') != ''
+				  AND NOT EXISTS(SELECT 1 FROM updated)
+				  AND NOT EXISTS(SELECT 1 FROM recipe_images WHERE recipe_id = recipe.id);`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	return
+}
+
+// deleteUnusedImages removes the given images from storage unless a recipe still uses them as its image, in its
+// gallery or in its steps. Forks share the images of the original recipe.
+func deleteUnusedImages(imageKeys []string) (err error) {
+	var unusedImageKeys []storage.ObjectKey
+
+	err = database.GetMultipleRecordsNamedQuery(
+		&unusedImageKeys,
+		`SELECT DISTINCT candidates.url
+				FROM UNNEST(CAST(:image_keys AS TEXT[])) AS candidates(url)
+				WHERE candidates.url != ''
+				  AND NOT EXISTS(SELECT 1 FROM recipes WHERE recipes.image_url = candidates.url)
+				  AND NOT EXISTS(SELECT 1 FROM recipe_images WHERE recipe_images.image_url = candidates.url)
+				  AND NOT EXISTS(SELECT 1
+								 FROM recipes,
+									  JSON_ARRAY_ELEMENTS(CAST(recipes.steps AS JSON)) AS step
+								 WHERE JSON_TYPEOF(step) = 'object'
+								   AND step ->> 'imageURL' = candidates.url);`,
+		map[string]interface{}{"image_keys": pq.StringArray(imageKeys)},
+	)
+	if err != nil {
+		return
+	}
+
+	for _, imageKey := range unusedImageKeys {
+		err = errors.Join(err, images.Delete(imageKey))
+	}
+	return
+}
+
+// deleteReplacedImages only logs a failed clean up, since the recipe changes are already saved
+func deleteReplacedImages(imageKeys []string) {
+	if err := deleteUnusedImages(imageKeys); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Warnf("Error on deleting the replaced recipe images %v", imageKeys)
+	}
+}
+
+func stepImageKeys(steps Steps) (imageKeys []string) {
+	for _, step := range steps {
+		if step.ImageURL != "" {
+			imageKeys = append(imageKeys, string(step.ImageURL))
+		}
+	}
+	return
+}
@@ -0,0 +1,163 @@
+package recipes
+
+import (
+	"encoding/json"
+	"fmt"
+	"recipes-v2-server/database"
+)
+
+// recipeNoteColumns are the columns a note is returned with, the recipe_notes table is joined with the recipes one
+const recipeNoteColumns = `recipe_notes.id,
+					   recipes.recipe_name,
+					   recipe_notes.step_number,
+					   recipe_notes.ingredient_index,
+					   recipe_notes.content,
+					   recipe_notes.created_at,
+					   recipe_notes.updated_at`
+
+// GetNotes gets the private notes the user left on the recipe. The notes on the whole recipe come first, followed by
+// the notes on its steps and products.
+func GetNotes(recipeName string, userId int) (notes []RecipeNote, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&notes,
+		`SELECT `+recipeNoteColumns+`
+				FROM recipe_notes
+						 JOIN recipes ON recipes.id = recipe_notes.recipe_id
+				WHERE recipes.recipe_name = :recipe_name
+				  AND recipe_notes.user_id = :user_id
+				  AND recipes.deleted_at IS NULL
+				ORDER BY recipe_notes.step_number NULLS FIRST,
+						 recipe_notes.ingredient_index NULLS FIRST,
+						 recipe_notes.created_at;`,
+		map[string]interface{}{"recipe_name": recipeName, "user_id": userId},
+	)
+	return
+}
+
+// GetAllNotes gets the private notes the user left on any recipe
+func GetAllNotes(userId int) (notes []RecipeNote, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&notes,
+		`SELECT `+recipeNoteColumns+`
+				FROM recipe_notes
+						 JOIN recipes ON recipes.id = recipe_notes.recipe_id
+				WHERE recipe_notes.user_id = :user_id
+				  AND recipes.deleted_at IS NULL
+				ORDER BY recipes.recipe_name, recipe_notes.created_at;`,
+		map[string]interface{}{"user_id": userId},
+	)
+	return
+}
+
+// CreateNote adds a private note of the user to the recipe. The note can be attached to a step, by its number starting
+// from 1, or to a product, by its index in the products list like the ingredients of the steps, but not to both.
+func CreateNote(recipeName string, userId int, request RecipeNoteRequest) (note RecipeNote, err error) {
+	var recipe RecipeData
+
+	err = database.GetSingleRecordNamedQuery(
+		&recipe,
+		`SELECT recipe_name, products, steps
+				FROM recipes
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		return
+	}
+
+	err = validateNote(recipe, request)
+	if err != nil {
+		return
+	}
+
+	err = database.GetSingleRecordNamedQuery(
+		&note,
+		`WITH inserted_note AS (INSERT INTO recipe_notes (user_id, recipe_id, step_number, ingredient_index, content, created_at, updated_at)
+						 SELECT :user_id, id, :step_number, :ingredient_index, :content, NOW(), NOW()
+						 FROM recipes
+						 WHERE recipe_name = :recipe_name
+						 RETURNING *)
+
+				SELECT inserted_note.id,
+					   :recipe_name AS recipe_name,
+					   step_number,
+					   ingredient_index,
+					   content,
+					   created_at,
+					   updated_at
+				FROM inserted_note;`,
+		map[string]interface{}{
+			"recipe_name":      recipeName,
+			"user_id":          userId,
+			"step_number":      request.StepNumber,
+			"ingredient_index": request.IngredientIndex,
+			"content":          request.Content,
+		},
+	)
+	return
+}
+
+// EditNote changes the text of a note of the user. The step or product the note is attached to stays the same.
+func EditNote(recipeName string, userId, id int, content string) (note RecipeNote, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&note,
+		`UPDATE recipe_notes
+				SET content    = :content,
+					updated_at = NOW()
+				FROM recipes
+				WHERE recipe_notes.id = :id
+				  AND recipe_notes.user_id = :user_id
+				  AND recipes.id = recipe_notes.recipe_id
+				  AND recipes.recipe_name = :recipe_name
+				  AND recipes.deleted_at IS NULL
+				RETURNING `+recipeNoteColumns+`;`,
+		map[string]interface{}{"recipe_name": recipeName, "user_id": userId, "id": id, "content": content},
+	)
+	return
+}
+
+// DeleteNote removes a note of the user from the recipe
+func DeleteNote(recipeName string, userId, id int) (err error) {
+	var deletedId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&deletedId,
+		`DELETE
+				FROM recipe_notes
+				WHERE id = :id
+				  AND user_id = :user_id
+				  AND recipe_id = (SELECT id FROM recipes WHERE recipe_name = :recipe_name)
+				RETURNING id;`,
+		map[string]interface{}{"recipe_name": recipeName, "user_id": userId, "id": id},
+	)
+	return
+}
+
+// InvalidNoteError describes a note attached to a step or product the recipe does not have
+type InvalidNoteError struct {
+	Message string
+}
+
+func (err *InvalidNoteError) Error() string {
+	return err.Message
+}
+
+func validateNote(recipe RecipeData, request RecipeNoteRequest) error {
+	if request.StepNumber != nil && request.IngredientIndex != nil {
+		return &InvalidNoteError{Message: "a note can be attached either to a step or to an ingredient"}
+	}
+
+	if request.StepNumber != nil && (*request.StepNumber < 1 || *request.StepNumber > len(recipe.Steps)) {
+		return &InvalidNoteError{Message: fmt.Sprintf("stepNumber: should be between 1 and %d", len(recipe.Steps))}
+	}
+
+	if request.IngredientIndex != nil {
+		var products []json.RawMessage
+		_ = json.Unmarshal(recipe.Products, &products)
+
+		if *request.IngredientIndex < 0 || *request.IngredientIndex >= len(products) {
+			return &InvalidNoteError{Message: fmt.Sprintf("ingredientIndex: %d does not reference an existing product", *request.IngredientIndex)}
+		}
+	}
+	return nil
+}
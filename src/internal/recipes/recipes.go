@@ -1,31 +1,42 @@
 package recipes
 
 import (
-	"bytes"
+	"database/sql"
 	"errors"
-	"mime/multipart"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/url"
 	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/internal/users"
+	"recipes-v2-server/storage"
 	"recipes-v2-server/utils"
 )
 
-// GetAll gets the recipes in a pageable way
-func GetAll(limit, cursor int) (recipes RecipePaginationInfo, err error) {
+// GetAll gets the recipes in a pageable way, with their names translated to the given language when available. A
+// positive maximum cost keeps only the recipes completely estimated to cost up to it in the filter currency.
+func GetAll(limit, cursor int, language string, costFilter CostFilter) (recipes RecipePaginationInfo, err error) {
 	offset := cursor
 
 	err = database.GetMultipleRecordsNamedQuery(
 		&recipes.BaseRecipeInfoArray,
-		`SELECT recipe_name, image_url
+		`SELECT recipes.recipe_name,
+					   recipes.image_url,
+					   COALESCE(recipe_translations.recipe_name, '') AS translated_name
 			FROM recipes
-			WHERE status = 'APPROVED'
-			ORDER BY created_at
+					 LEFT JOIN recipe_translations ON recipe_translations.recipe_id = recipes.id
+				AND recipe_translations.language = :language
+			WHERE status = 'APPROVED' AND deleted_at IS NULL
+			  AND `+maxCostFilter+`
+			ORDER BY recipes.created_at
 			LIMIT :limit OFFSET :offset;`,
-		map[string]interface{}{"limit": limit, "offset": offset},
+		map[string]interface{}{"limit": limit, "offset": offset, "language": language, "max_cost": costFilter.MaxCost, "currency": costFilter.Currency},
 	)
 	if err != nil {
 		return
 	}
 
-	totalRecipesCount, err := Count()
+	totalRecipesCount, err := countWithMaxCost(costFilter)
 	if err != nil {
 		return
 	}
@@ -50,67 +61,96 @@ func GetAll(limit, cursor int) (recipes RecipePaginationInfo, err error) {
 	return
 }
 
-// GetLatest gets the latest 3 recipes
-func GetLatest() (recipes []ExtendedRecipeInfo, err error) {
-	err = database.GetMultipleRecords(
+// GetLatest gets the latest 3 recipes, with their names translated to the given language when available
+func GetLatest(language string) (recipes []ExtendedRecipeInfo, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
 		&recipes,
-		`SELECT recipe_name,
-					   image_url,
-					   category
+		`SELECT recipes.recipe_name,
+					   recipes.image_url,
+					   recipes.category,
+					   COALESCE(recipe_translations.recipe_name, '') AS translated_name
 				FROM recipes
-				WHERE status = 'APPROVED'
-				ORDER BY created_at DESC
+						 LEFT JOIN recipe_translations ON recipe_translations.recipe_id = recipes.id
+					AND recipe_translations.language = :language
+				WHERE status = 'APPROVED' AND deleted_at IS NULL
+				ORDER BY recipes.created_at DESC
 				LIMIT 3;`,
+		map[string]interface{}{"language": language},
 	)
 	return
 }
 
-// GetMostPopular gets the most visited 3 recipes
-func GetMostPopular() (recipes []BaseRecipeInfo, err error) {
-	err = database.GetMultipleRecords(
+// GetMostPopular gets the most visited 3 recipes, with their names translated to the given language when available
+func GetMostPopular(language string) (recipes []BaseRecipeInfo, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
 		&recipes,
-		`SELECT recipe_name,
-					   image_url
+		`SELECT recipes.recipe_name,
+					   recipes.image_url,
+					   COALESCE(recipe_translations.recipe_name, '') AS translated_name
 				FROM recipes
+						 LEFT JOIN recipe_translations ON recipe_translations.recipe_id = recipes.id
+					AND recipe_translations.language = :language
+				WHERE status = 'APPROVED' AND deleted_at IS NULL
 				ORDER BY visitations_count DESC
 				LIMIT 3;`,
+		map[string]interface{}{"language": language},
 	)
 	return
 }
 
-// Search searches for recipes by name with the provided string
-func Search(query string) (recipes []BaseRecipeInfo, err error) {
+// Search searches for recipes by their original or translated names with the provided string. The names are
+// translated to the given language when available. A positive maximum cost keeps only the recipes completely estimated
+// to cost up to it in the filter currency.
+func Search(query, language string, costFilter CostFilter) (recipes []BaseRecipeInfo, err error) {
 	filter := "%" + query + "%"
 
 	err = database.GetMultipleRecordsNamedQuery(
 		&recipes,
-		`SELECT recipe_name,
-					   image_url
+		`SELECT recipes.recipe_name,
+					   recipes.image_url,
+					   COALESCE(recipe_translations.recipe_name, '') AS translated_name
 				FROM recipes
-				WHERE recipe_name LIKE :query AND status = 'APPROVED'
+						 LEFT JOIN recipe_translations ON recipe_translations.recipe_id = recipes.id
+					AND recipe_translations.language = :language
+				WHERE (recipes.recipe_name LIKE :query
+					OR EXISTS(SELECT 1
+							  FROM recipe_translations AS translations
+							  WHERE translations.recipe_id = recipes.id
+								AND translations.recipe_name LIKE :query))
+				  AND status = 'APPROVED'
+				  AND deleted_at IS NULL
+				  AND `+maxCostFilter+`
 				ORDER BY visitations_count DESC;`,
-		map[string]interface{}{"query": filter},
+		map[string]interface{}{"query": filter, "language": language, "max_cost": costFilter.MaxCost, "currency": costFilter.Currency},
 	)
 
 	return
 }
 
-// SearchByCategory searches for recipes by category name with the provided string
-func SearchByCategory(query string) (recipes []BaseRecipeInfo, err error) {
+// SearchByCategory searches for recipes by category name with the provided string. The names are translated to the
+// given language when available. A positive maximum cost keeps only the recipes completely estimated to cost up to it
+// in the filter currency.
+func SearchByCategory(query, language string, costFilter CostFilter) (recipes []BaseRecipeInfo, err error) {
 	err = database.GetMultipleRecordsNamedQuery(
 		&recipes,
-		`SELECT recipe_name,
-					   image_url
+		`SELECT recipes.recipe_name,
+					   recipes.image_url,
+					   COALESCE(recipe_translations.recipe_name, '') AS translated_name
 				FROM recipes
-				WHERE category = :query AND status = 'APPROVED'
+						 LEFT JOIN recipe_translations ON recipe_translations.recipe_id = recipes.id
+					AND recipe_translations.language = :language
+				WHERE category = :query AND status = 'APPROVED' AND deleted_at IS NULL
+				  AND `+maxCostFilter+`
 				ORDER BY visitations_count DESC;`,
-		map[string]interface{}{"query": query},
+		map[string]interface{}{"query": query, "language": language, "max_cost": costFilter.MaxCost, "currency": costFilter.Currency},
 	)
 	return
 }
 
-// GetASingleRecipe gets the recipe with provided name from the database
-func GetASingleRecipe(recipeName string) (recipe RecipeData, err error) {
+// GetASingleRecipe gets the recipe with provided name from the database. Its texts are translated to the given
+// language when a translation is available, otherwise the recipe is returned in its original language. The private
+// notes of the viewer are included, pass 0 for anonymous viewers.
+func GetASingleRecipe(recipeName, language string, viewerId int) (recipe RecipeData, err error) {
 	err = database.GetSingleRecordNamedQuery(
 		&recipe,
 		`SELECT recipe_name,
@@ -118,17 +158,189 @@ func GetASingleRecipe(recipeName string) (recipe RecipeData, err error) {
 					   COALESCE(calories, 0)                   AS calories,
 					   preparation_time,
 					   COALESCE(protein, 0)                    AS protein,
+					   servings,
 					   difficulty,
 					   steps,
 					   products,
 					   category,
+					   recipes.language,
 					   users.id                                AS owner_id,
-					   users.username                          AS owner_name
+					   users.username                          AS owner_name,
+					   (SELECT COUNT(*)
+						FROM cook_logs
+								 JOIN users AS cooks ON cooks.id = cook_logs.user_id
+						WHERE cook_logs.recipe_id = recipes.id
+						  AND cooks.deleted_at IS NULL)        AS times_cooked
 				FROM recipes
 						 LEFT JOIN users ON users.id = recipes.owner_id
-				WHERE recipe_name = :recipe_name;`,
+				WHERE recipe_name = :recipe_name AND recipes.deleted_at IS NULL;`,
 		map[string]interface{}{"recipe_name": recipeName},
 	)
+	if err != nil {
+		return
+	}
+
+	recipe.Images, err = GetImages(recipeName)
+	if err != nil {
+		return
+	}
+
+	recipe.AdaptedFrom, err = getAttribution(recipeName)
+	if err != nil {
+		return
+	}
+
+	recipe.Cost, err = getCostEstimate(recipeName)
+	if err != nil {
+		return
+	}
+
+	if viewerId != 0 {
+		recipe.Notes, err = GetNotes(recipeName, viewerId)
+		if err != nil {
+			return
+		}
+	}
+
+	err = translate(&recipe, language)
+	return
+}
+
+// getAttribution gets the original recipe and author of a forked recipe. The attribution is kept on the fork itself,
+// so it is still returned after the original recipe is deleted. Returns nil for recipes that are not forks.
+func getAttribution(recipeName string) (attribution *Attribution, err error) {
+	var result Attribution
+
+	err = database.GetSingleRecordNamedQuery(
+		&result,
+		`SELECT COALESCE(original.recipe_name, fork.forked_from_recipe_name) AS recipe_name,
+					   COALESCE(original_owner.username, fork.forked_from_username) AS username,
+					   original.id IS NOT NULL                                      AS is_original_available
+				FROM recipes AS fork
+						 LEFT JOIN recipes AS original ON original.id = fork.forked_from_id AND original.deleted_at IS NULL
+						 LEFT JOIN users AS original_owner ON original_owner.id = original.owner_id
+				WHERE fork.recipe_name = :recipe_name
+				  AND fork.forked_from_recipe_name IS NOT NULL;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return
+	}
+	return &result, nil
+}
+
+// Fork copies an approved recipe into a new draft owned by the given user, recording the original recipe and its
+// author for attribution
+func Fork(recipeName, newRecipeName string, owner users.OwnerData) (response RecipeData, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&response,
+		`INSERT INTO recipes (category,
+                     created_at,
+                     image_url,
+                     owner_id,
+                     recipe_name,
+                     status,
+                     visitations_count,
+                     calories,
+                     protein,
+                     servings,
+                     preparation_time,
+                     difficulty,
+                     steps,
+                     products,
+                     forked_from_id,
+                     forked_from_recipe_name,
+                     forked_from_username,
+                     language)
+				SELECT category,
+					   NOW(),
+					   image_url,
+					   :owner_id,
+					   :new_recipe_name,
+					   'DRAFT',
+					   0,
+					   calories,
+					   protein,
+					   servings,
+					   preparation_time,
+					   difficulty,
+					   steps,
+					   products,
+					   recipes.id,
+					   recipes.recipe_name,
+					   users.username,
+					   recipes.language
+				FROM recipes
+						 LEFT JOIN users ON users.id = recipes.owner_id
+				WHERE recipe_name = :recipe_name AND status = 'APPROVED' AND recipes.deleted_at IS NULL
+				RETURNING recipe_name,
+					image_url,
+					COALESCE(calories, 0) AS calories,
+					preparation_time,
+					COALESCE(protein, 0) AS protein,
+					servings,
+					difficulty,
+					steps,
+					products,
+					category,
+					language,
+					owner_id;`,
+		map[string]interface{}{"recipe_name": recipeName, "new_recipe_name": newRecipeName, "owner_id": owner.Id},
+	)
+	if err != nil {
+		return
+	}
+
+	err = syncCoverImage(newRecipeName)
+	if err != nil {
+		return
+	}
+	estimateCost(newRecipeName)
+
+	response.OwnerData.Username = owner.Username
+	response.AdaptedFrom, err = getAttribution(newRecipeName)
+	return
+}
+
+// GetForks gets the approved forks of the given recipe
+func GetForks(recipeName string) (forks []ForkInfo, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&forks,
+		`SELECT fork.recipe_name,
+					   fork.image_url,
+					   users.username AS owner_name
+				FROM recipes AS fork
+						 JOIN recipes AS original ON original.id = fork.forked_from_id
+						 JOIN users ON users.id = fork.owner_id
+				WHERE original.recipe_name = :recipe_name
+				  AND fork.status = 'APPROVED'
+				  AND fork.deleted_at IS NULL
+				ORDER BY fork.created_at DESC;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	return
+}
+
+// Publish submits a draft recipe for approval, or approves it directly when published by an admin or moderator
+func Publish(recipeName string, authToken string) (err error) {
+	recipe, err := adjustRecipeStatus(RecipeData{RecipeName: recipeName}, authToken)
+	if err != nil {
+		return
+	}
+
+	var publishedRecipeName string
+
+	err = database.GetSingleRecordNamedQuery(
+		&publishedRecipeName,
+		`UPDATE recipes
+				SET status = :status
+				WHERE recipe_name = :recipe_name AND status = 'DRAFT' AND deleted_at IS NULL
+				RETURNING recipe_name;`,
+		recipe,
+	)
 	return
 }
 
@@ -140,7 +352,7 @@ func GetRecipesFromUser(username string) (recipes []BaseRecipeInfo, err error) {
 					   image_url
 				FROM recipes
 						 JOIN users ON recipes.owner_id = users.id
-				WHERE username = :username
+				WHERE username = :username AND recipes.deleted_at IS NULL AND users.deleted_at IS NULL
 				ORDER BY visitations_count DESC;`,
 		map[string]interface{}{"username": username},
 	)
@@ -156,7 +368,7 @@ func GetFavourites(username string) (recipes []BaseRecipeInfo, err error) {
 				FROM users
 						 JOIN users_favourites ON users_favourites.user_entity_id = users.id
 						 JOIN recipes ON recipes.id = users_favourites.favourites_id
-				WHERE username = :username
+				WHERE username = :username AND recipes.deleted_at IS NULL AND users.deleted_at IS NULL
 				ORDER BY visitations_count DESC;`,
 		map[string]interface{}{"username": username},
 	)
@@ -172,7 +384,7 @@ func IsInFavourites(data FavouritesRequest) (isInFavourites bool, err error) {
                        JOIN users_favourites
                             ON recipes.id = users_favourites.favourites_id
                                 AND users_favourites.user_entity_id = :user_id
-              WHERE recipe_name = :recipe_name);`,
+              WHERE recipe_name = :recipe_name AND deleted_at IS NULL);`,
 		data,
 	)
 	return
@@ -181,7 +393,7 @@ func IsInFavourites(data FavouritesRequest) (isInFavourites bool, err error) {
 // AddToFavourites adds and recipe to the user favourites collection
 func AddToFavourites(data FavouritesRequest) (err error) {
 	_, err = database.ExecuteNamedQuery(
-		`WITH recipes AS (SELECT id FROM recipes WHERE recipe_name = :recipe_name)
+		`WITH recipes AS (SELECT id FROM recipes WHERE recipe_name = :recipe_name AND deleted_at IS NULL)
 				
 				INSERT
 				INTO users_favourites(user_entity_id, favourites_id)
@@ -212,6 +424,10 @@ func Create(recipe RecipeData, authToken string) (response RecipeData, err error
 		return
 	}
 
+	if recipe.Language == "" {
+		recipe.Language = OriginalLanguage
+	}
+
 	err = database.GetSingleRecordNamedQuery(
 		&response,
 		`INSERT INTO recipes (category,
@@ -223,10 +439,12 @@ func Create(recipe RecipeData, authToken string) (response RecipeData, err error
                      visitations_count,
                      calories,
                      protein,
+                     servings,
                      preparation_time,
                      difficulty,
                      steps,
-                     products)
+                     products,
+                     language)
 				VALUES (:category,
 						NOW(),
 						:image_url,
@@ -236,24 +454,49 @@ func Create(recipe RecipeData, authToken string) (response RecipeData, err error
 						0,
 						:calories,
 						:protein,
+						:servings,
 						:preparation_time,
 						:difficulty,
 						:steps,
-						:products)
+						:products,
+						:language)
 				RETURNING recipe_name,
 					image_url,
 					COALESCE(calories, 0) AS calories,
 					preparation_time,
 					COALESCE(protein, 0) AS protein,
+					servings,
 					difficulty,
 					steps,
 					products,
 					category,
+					language,
 					owner_id;`,
 		recipe,
 	)
+	if err != nil {
+		return
+	}
+
+	err = syncCoverImage(recipe.RecipeName)
+	if err != nil {
+		return
+	}
+	estimateCost(recipe.RecipeName)
 
 	response.OwnerData.Username = recipe.OwnerData.Username
+
+	response.PossibleDuplicates, err = FindDuplicates(recipe.RecipeName, recipe.Products)
+	if err == nil {
+		err = flagIfDuplicate(recipe.RecipeName, response.PossibleDuplicates)
+	}
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Warnf("Error on duplicate check for recipe %s", recipe.RecipeName)
+		err = nil
+	}
 	return
 }
 
@@ -284,28 +527,88 @@ func RecipeNameExists(recipeName string) (exists bool, err error) {
 	return
 }
 
-// UploadRecipeImage Uploads recipe image to s3 bucket and returns the URL
-func UploadRecipeImage(file *multipart.FileHeader, fileKey string) (imageURL string, err error) {
-	contentType := file.Header.Get("Content-Type")
+// UploadRecipeImage processes the recipe image into its variants, uploads them to the storage and returns their URLs.
+// The upload is recorded for the user, so only they can use it as the image of a recipe.
+func UploadRecipeImage(content io.Reader, userId int) (variants images.Variants, err error) {
+	variants, err = images.Upload(content, images.RecipeImage)
+	if err != nil {
+		return
+	}
+
+	_, err = database.ExecuteNamedQuery(
+		`INSERT INTO recipe_image_uploads (image_key, user_id, uploaded_at)
+				VALUES (:image_key, :user_id, NOW())
+				ON CONFLICT (image_key, user_id) DO UPDATE SET uploaded_at = NOW();`,
+		map[string]interface{}{"image_key": variants.Full, "user_id": userId},
+	)
+	return
+}
+
+// InvalidImageError describes a recipe image that is neither an uploaded image nor an http(s) URL
+type InvalidImageError struct {
+	Message string
+}
+
+func (err *InvalidImageError) Error() string {
+	return err.Message
+}
 
-	fileContent, _ := file.Open()
-	buffer := make([]byte, file.Size)
-	_, _ = fileContent.Read(buffer)
-	fileBytes := bytes.NewReader(buffer)
+// ValidateImage checks that the recipe image is an http(s) URL or the key of an uploaded image - a processed upload of
+// the requester or a picture of the recipe gallery. An edited recipe can also keep its current image. Pass an empty
+// recipe name for a new recipe.
+func ValidateImage(recipeName string, imageURL storage.ObjectKey, requesterId int) (err error) {
+	if storage.IsAbsoluteURL(string(imageURL)) {
+		if parsed, parseErr := url.Parse(string(imageURL)); parseErr != nil || parsed.Host == "" {
+			return &InvalidImageError{Message: "imageURL: should be a valid http(s) URL"}
+		}
+		return nil
+	}
 
-	err = utils.UploadToS3(fileBytes, fileKey, contentType)
+	var isUploaded bool
+
+	err = database.GetSingleRecordNamedQuery(
+		&isUploaded,
+		`SELECT EXISTS(SELECT 1
+					          FROM recipe_image_uploads
+					                   JOIN image_variants ON image_variants.full_key = recipe_image_uploads.image_key
+					          WHERE image_key = :image_url
+					            AND user_id = :requester_id)
+					OR EXISTS(SELECT 1
+					          FROM recipe_images
+					                   JOIN recipes ON recipes.id = recipe_images.recipe_id
+					          WHERE recipe_name = :recipe_name
+					            AND recipe_images.image_url = :image_url)
+					OR EXISTS(SELECT 1 FROM recipes WHERE recipe_name = :recipe_name AND image_url = :image_url);`,
+		map[string]interface{}{"recipe_name": recipeName, "image_url": imageURL, "requester_id": requesterId},
+	)
 	if err != nil {
 		return
 	}
 
-	imageURL = utils.GetTheFullS3BucketURL() + "/" + fileKey
-	return
+	if !isUploaded {
+		return &InvalidImageError{Message: "imageURL: should be an uploaded image or an http(s) URL"}
+	}
+	return nil
 }
 
-// Edit edits a recipe
+// Edit edits a recipe. Zero servings keep the stored ones, since the recipes created before the servings were recorded
+// have none to send back.
 func Edit(recipeName string, data RecipeData) (result RecipeData, err error) {
 	extendedData := ExtendedRecipeData{data, recipeName}
 
+	var previous RecipeData
+
+	err = database.GetSingleRecordNamedQuery(
+		&previous,
+		`SELECT COALESCE(image_url, '') AS image_url, steps
+				FROM recipes
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		return
+	}
+
 	err = database.GetSingleRecordNamedQuery(
 		&result,
 		`UPDATE recipes
@@ -315,43 +618,58 @@ func Edit(recipeName string, data RecipeData) (result RecipeData, err error) {
 					image_url        = :image_url,
 					calories         = :calories,
 					protein          = :protein,
+					servings         = COALESCE(NULLIF(:servings, 0), servings),
 					difficulty       = :difficulty,
 					steps            = :steps,
 					products         = :products
-				WHERE recipe_name = :old_recipe_name
+				WHERE recipe_name = :old_recipe_name AND deleted_at IS NULL
 				RETURNING *`,
 		extendedData,
 	)
+	if err != nil {
+		return
+	}
+
+	err = syncCoverImage(result.RecipeName)
+	if err != nil {
+		return
+	}
+	estimateCost(result.RecipeName)
+
+	deleteReplacedImages(append(stepImageKeys(previous.Steps), string(previous.ImageURL)))
 	return
 }
 
-// Delete deletes a recipe
+// Delete moves a recipe to the trash. It is purged together with its images once the trash retention period passes.
 func Delete(recipeName string) (err error) {
-	var oldImageURL string
+	var deletedId int
 
 	err = database.GetSingleRecordNamedQuery(
-		&oldImageURL,
-		`WITH recipe AS (SELECT id FROM recipes WHERE recipe_name = :recipe_name),
-					 delete_favourites AS (DELETE FROM users_favourites WHERE favourites_id = (SELECT recipe.id FROM recipe)),
-     				 delete_comments AS (DELETE FROM comments WHERE target_recipe_id = (SELECT recipe.id FROM recipe))
-				
-				DELETE
-				FROM recipes
-				WHERE recipe_name = :recipe_name
-				RETURNING recipes.image_url;`,
+		&deletedId,
+		`UPDATE recipes
+				SET deleted_at = NOW()
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL
+				RETURNING id;`,
 		map[string]interface{}{"recipe_name": recipeName},
 	)
-	if err != nil {
-		return
-	}
-
-	err = utils.DeleteFromS3(oldImageURL)
 	return
 }
 
 // Count retrieves the total count of the recipes
 func Count() (count int, err error) {
-	err = database.GetSingleRecord(&count, `SELECT COUNT(id) FROM recipes WHERE status = 'APPROVED';`)
+	err = database.GetSingleRecord(&count, `SELECT COUNT(id) FROM recipes WHERE status = 'APPROVED' AND deleted_at IS NULL;`)
+	return
+}
+
+func countWithMaxCost(costFilter CostFilter) (count int, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&count,
+		`SELECT COUNT(id)
+				FROM recipes
+				WHERE status = 'APPROVED' AND deleted_at IS NULL
+				  AND `+maxCostFilter+`;`,
+		map[string]interface{}{"max_cost": costFilter.MaxCost, "currency": costFilter.Currency},
+	)
 	return
 }
 
@@ -359,48 +677,132 @@ func Count() (count int, err error) {
 func GetAllAdmin() (recipeData []AdminRecipeData, err error) {
 	err = database.GetMultipleRecords(
 		&recipeData,
-		`SELECT recipe_name,
-					   image_url,
-					   status,
+		`SELECT recipes.recipe_name,
+					   recipes.image_url,
+					   recipes.status,
 					   recipes.id,
-					   username AS owner_name
+					   username                                AS owner_name,
+					   COALESCE(duplicate_of.recipe_name, '')  AS suspected_duplicate_of,
+					   COALESCE(recipes.duplicate_score, 0)    AS duplicate_score,
+					   recipes.publish_at
 				FROM recipes
-						 JOIN users ON recipes.owner_id = users.id;`,
+						 JOIN users ON recipes.owner_id = users.id
+						 LEFT JOIN recipes AS duplicate_of ON duplicate_of.id = recipes.suspected_duplicate_of_id
+				WHERE recipes.deleted_at IS NULL;`,
 	)
 	return
 }
 
-// AdminDelete deletes a recipe
+// AdminDelete moves a recipe to the trash, from where admins can restore it until the trash retention period passes
 func AdminDelete(id int) (err error) {
-	var oldImageURL string
+	var deletedId int
 
 	err = database.GetSingleRecordNamedQuery(
-		&oldImageURL,
-		`WITH recipe AS (SELECT id FROM recipes WHERE id = :id),
-					 delete_favourites AS (DELETE FROM users_favourites WHERE favourites_id = :id),
-     				 delete_comments AS (DELETE FROM comments WHERE target_recipe_id = :id)
-				
-				DELETE
-				FROM recipes
-				WHERE id = :id
-				RETURNING COALESCE(recipes.image_url, '');`,
+		&deletedId,
+		`UPDATE recipes SET deleted_at = NOW() WHERE id = :id AND deleted_at IS NULL RETURNING id;`,
+		map[string]interface{}{"id": id},
+	)
+	return
+}
+
+// Approve approves a recipe right away, also when it was scheduled for later
+func Approve(id int) (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`UPDATE recipes SET status = 'APPROVED', publish_at = NULL WHERE id = :id AND deleted_at IS NULL`,
 		map[string]interface{}{"id": id},
 	)
+	return
+}
+
+// GetFeed gets approved recipes the user has not seen yet, ranked by how much the user interacted with their category
+// through favourites, comments and views and by how similar they are to the user favourites. Recipes from users
+// hidden by the user are excluded. When there are not enough personalized results the feed is filled up with the
+// trending recipes.
+func GetFeed(userId, limit int) (recipes []ExtendedRecipeInfo, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&recipes,
+		`WITH interactions AS (SELECT recipes.category, 3 AS weight
+									FROM users_favourites
+											 JOIN recipes ON recipes.id = users_favourites.favourites_id
+									WHERE user_entity_id = :user_id
+									UNION ALL
+									SELECT recipes.category, 2 AS weight
+									FROM comments
+											 JOIN recipes ON recipes.id = comments.target_recipe_id
+									WHERE comments.owner_id = :user_id
+									UNION ALL
+									SELECT recipes.category, 1 AS weight
+									FROM user_recipe_views
+											 JOIN recipes ON recipes.id = user_recipe_views.recipe_id
+									WHERE user_id = :user_id),
+
+					 category_affinity AS (SELECT category, SUM(weight) AS affinity FROM interactions GROUP BY category),
+
+					 similar_to_favourites AS (SELECT similar_recipe_id, SUM(score) AS similarity
+											   FROM recipe_similarities
+														JOIN users_favourites ON users_favourites.favourites_id = recipe_similarities.recipe_id
+											   WHERE user_entity_id = :user_id
+											   GROUP BY similar_recipe_id)
+
+				SELECT recipe_name,
+					   image_url,
+					   recipes.category
+				FROM recipes
+						 LEFT JOIN category_affinity ON category_affinity.category = recipes.category
+						 LEFT JOIN similar_to_favourites ON similar_to_favourites.similar_recipe_id = recipes.id
+				WHERE status = 'APPROVED' AND deleted_at IS NULL
+				  AND (category_affinity.affinity IS NOT NULL OR similar_to_favourites.similarity IS NOT NULL)
+				  AND recipes.owner_id != :user_id
+				  AND recipes.owner_id NOT IN (SELECT hidden_user_id FROM hidden_users WHERE user_id = :user_id)
+				  AND recipes.id NOT IN (SELECT recipe_id FROM user_recipe_views WHERE user_id = :user_id
+										 UNION
+										 SELECT favourites_id FROM users_favourites WHERE user_entity_id = :user_id
+										 UNION
+										 SELECT target_recipe_id FROM comments WHERE owner_id = :user_id)
+				ORDER BY COALESCE(category_affinity.affinity, 0) + 5 * COALESCE(similar_to_favourites.similarity, 0) DESC,
+						 visitations_count DESC
+				LIMIT :limit;`,
+		map[string]interface{}{"user_id": userId, "limit": limit},
+	)
+	if err != nil || len(recipes) >= limit {
+		return
+	}
+
+	fallback, err := getFeedFallback(userId, limit)
 	if err != nil {
 		return
 	}
 
-	if oldImageURL != "" {
-		err = utils.DeleteFromS3(oldImageURL)
+	included := map[string]struct{}{}
+	for _, recipe := range recipes {
+		included[recipe.RecipeName] = struct{}{}
+	}
+
+	for _, recipe := range fallback {
+		if _, ok := included[recipe.RecipeName]; ok || len(recipes) >= limit {
+			continue
+		}
+		recipes = append(recipes, recipe)
 	}
 	return
 }
 
-// Approve approves a recipe
-func Approve(id int) (err error) {
-	_, err = database.ExecuteNamedQuery(
-		`UPDATE recipes SET status = 'APPROVED' WHERE id = :id`,
-		map[string]interface{}{"id": id},
+// getFeedFallback gets the trending approved recipes for users without enough activity for a personalized feed
+func getFeedFallback(userId, limit int) (recipes []ExtendedRecipeInfo, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&recipes,
+		`SELECT recipe_name,
+					   image_url,
+					   category
+				FROM recipes
+						 LEFT JOIN recipe_trending_scores ON recipe_trending_scores.recipe_id = recipes.id
+				WHERE status = 'APPROVED' AND deleted_at IS NULL
+				  AND owner_id != :user_id
+				  AND owner_id NOT IN (SELECT hidden_user_id FROM hidden_users WHERE user_id = :user_id)
+				  AND id NOT IN (SELECT recipe_id FROM user_recipe_views WHERE user_id = :user_id)
+				ORDER BY COALESCE(recipe_trending_scores.score, 0) DESC, visitations_count DESC
+				LIMIT :limit;`,
+		map[string]interface{}{"user_id": userId, "limit": limit},
 	)
 	return
 }
@@ -0,0 +1,74 @@
+package recipes
+
+import (
+	"recipes-v2-server/database"
+	"time"
+)
+
+// Schedule approves a pending recipe to go live at the given time, stored as an instant regardless of the offset it
+// was sent with. Scheduled recipes can be rescheduled until they are published.
+func Schedule(id int, publishAt time.Time) (scheduled ScheduledRecipe, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&scheduled,
+		`UPDATE recipes
+				SET status     = 'SCHEDULED',
+					publish_at = :publish_at
+				FROM users
+				WHERE recipes.id = :id
+				  AND users.id = recipes.owner_id
+				  AND recipes.status IN ('PENDING', 'SCHEDULED')
+				  AND recipes.deleted_at IS NULL
+				RETURNING recipes.id,
+						  recipes.recipe_name,
+						  recipes.image_url,
+						  recipes.publish_at,
+						  users.id                       AS owner_id,
+						  users.username                 AS owner_name,
+						  COALESCE(users.avatar_url, '') AS owner_avatar;`,
+		map[string]interface{}{"id": id, "publish_at": publishAt.UTC()},
+	)
+	return
+}
+
+// GetScheduled gets the recipes waiting to be published, the next one first
+func GetScheduled() (scheduled []ScheduledRecipe, err error) {
+	err = database.GetMultipleRecords(
+		&scheduled,
+		`SELECT recipes.id,
+					   recipes.recipe_name,
+					   recipes.image_url,
+					   recipes.publish_at,
+					   users.id                       AS owner_id,
+					   users.username                 AS owner_name,
+					   COALESCE(users.avatar_url, '') AS owner_avatar
+				FROM recipes
+						 JOIN users ON users.id = recipes.owner_id
+				WHERE recipes.status = 'SCHEDULED'
+				  AND recipes.deleted_at IS NULL
+				ORDER BY recipes.publish_at;`,
+	)
+	return
+}
+
+// PublishScheduled approves the scheduled recipes whose time has come. They are returned together with their owners,
+// so the creation notifications can be sent.
+func PublishScheduled() (published []ScheduledRecipe, err error) {
+	err = database.GetMultipleRecords(
+		&published,
+		`UPDATE recipes
+				SET status = 'APPROVED'
+				FROM users
+				WHERE users.id = recipes.owner_id
+				  AND recipes.status = 'SCHEDULED'
+				  AND recipes.publish_at <= NOW()
+				  AND recipes.deleted_at IS NULL
+				RETURNING recipes.id,
+						  recipes.recipe_name,
+						  recipes.image_url,
+						  recipes.publish_at,
+						  users.id                       AS owner_id,
+						  users.username                 AS owner_name,
+						  COALESCE(users.avatar_url, '') AS owner_avatar;`,
+	)
+	return
+}
@@ -0,0 +1,184 @@
+package recipes
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lib/pq"
+	"math"
+	"recipes-v2-server/database"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const similarRecipesPerRecipe = 6
+
+// measurementWords are ignored when the products of a recipe are reduced to a set of ingredient words
+var measurementWords = map[string]struct{}{
+	"гр": {}, "грама": {}, "кг": {}, "мл": {}, "литър": {}, "литра": {}, "бр": {}, "броя": {}, "брой": {},
+	"ч.л": {}, "ч.л.": {}, "с.л": {}, "с.л.": {}, "чаена": {}, "чаени": {}, "супена": {}, "супени": {},
+	"лъжица": {}, "лъжици": {}, "лъжичка": {}, "лъжички": {}, "чаша": {}, "чаши": {}, "щипка": {}, "на": {},
+	"за": {}, "или": {}, "g": {}, "kg": {}, "ml": {}, "tbsp": {}, "tsp": {}, "cup": {}, "cups": {}, "pinch": {},
+	"the": {}, "and": {}, "for": {}, "pcs": {},
+}
+
+// IngredientSet reduces the products of a recipe to a set of normalized ingredient words, ignoring quantities and
+// measurement units. Products can be plain text or objects with a name / product field.
+func IngredientSet(products json.RawMessage) map[string]struct{} {
+	ingredients := map[string]struct{}{}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(products, &items); err != nil {
+		return ingredients
+	}
+
+	for _, item := range items {
+		for _, word := range strings.FieldsFunc(strings.ToLower(productText(item)), isNotIngredientLetter) {
+			if _, isMeasurement := measurementWords[word]; isMeasurement {
+				continue
+			}
+			// the dots are kept only inside the abbreviated units, like ч.л.
+			word = strings.Trim(word, ".")
+			if _, isMeasurement := measurementWords[word]; isMeasurement || len([]rune(word)) < 3 {
+				continue
+			}
+			ingredients[word] = struct{}{}
+		}
+	}
+	return ingredients
+}
+
+func productText(product json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(product, &text); err == nil {
+		return text
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(product, &fields); err != nil {
+		return ""
+	}
+	for _, key := range []string{"name", "product", "ingredient"} {
+		if value, ok := fields[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func isNotIngredientLetter(character rune) bool {
+	return !unicode.IsLetter(character) && character != '.'
+}
+
+// Jaccard returns the size of the intersection of the two sets divided by the size of their union
+func Jaccard(first, second map[string]struct{}) float64 {
+	if len(first) == 0 && len(second) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for word := range first {
+		if _, ok := second[word]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(first)+len(second)-intersection)
+}
+
+// GetSimilar gets the cached similar recipes of the given recipe, best matches first
+func GetSimilar(recipeName string) (recipes []ExtendedRecipeInfo, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&recipes,
+		`SELECT similar_recipe.recipe_name,
+					   similar_recipe.image_url,
+					   similar_recipe.category
+				FROM recipe_similarities
+						 JOIN recipes ON recipes.id = recipe_similarities.recipe_id
+						 JOIN recipes AS similar_recipe ON similar_recipe.id = recipe_similarities.similar_recipe_id
+				WHERE recipes.recipe_name = :recipe_name
+				  AND similar_recipe.status = 'APPROVED'
+				  AND similar_recipe.deleted_at IS NULL
+				ORDER BY score DESC;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	return
+}
+
+// ComputeSimilarities ranks every approved recipe against the others by ingredient overlap, shared category and
+// popularity and replaces the cached similar recipes with the best matches
+func ComputeSimilarities() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.JobTimeout)
+	defer cancel()
+
+	var candidates []similarityCandidate
+
+	err = database.GetMultipleRecordsContext(
+		ctx,
+		&candidates,
+		`SELECT id, category, products, visitations_count FROM recipes WHERE status = 'APPROVED' AND deleted_at IS NULL;`,
+	)
+	if err != nil {
+		return
+	}
+
+	maxVisitations := 0
+	for index := range candidates {
+		candidates[index].ingredients = IngredientSet(candidates[index].Products)
+		maxVisitations = max(maxVisitations, candidates[index].VisitationsCount)
+	}
+
+	var recipeIds, similarRecipeIds pq.Int32Array
+	var scores pq.Float64Array
+
+	for _, recipe := range candidates {
+		for _, match := range rankSimilar(recipe, candidates, maxVisitations) {
+			recipeIds = append(recipeIds, int32(recipe.Id))
+			similarRecipeIds = append(similarRecipeIds, int32(match.Id))
+			scores = append(scores, match.score)
+		}
+	}
+
+	_, err = database.ExecuteNamedQueryContext(
+		ctx,
+		`WITH delete_previous AS (DELETE FROM recipe_similarities)
+
+				INSERT
+				INTO recipe_similarities (recipe_id, similar_recipe_id, score, computed_at)
+				SELECT recipe_id, similar_recipe_id, score, NOW()
+				FROM UNNEST(CAST(:recipe_ids AS INT[]), CAST(:similar_recipe_ids AS INT[]), CAST(:scores AS FLOAT8[]))
+						 AS similarities(recipe_id, similar_recipe_id, score);`,
+		map[string]interface{}{"recipe_ids": recipeIds, "similar_recipe_ids": similarRecipeIds, "scores": scores},
+	)
+	return
+}
+
+func rankSimilar(recipe similarityCandidate, candidates []similarityCandidate, maxVisitations int) (matches []similarityCandidate) {
+	for _, candidate := range candidates {
+		if candidate.Id == recipe.Id {
+			continue
+		}
+
+		ingredientOverlap := Jaccard(recipe.ingredients, candidate.ingredients)
+		sameCategory := candidate.CategoryName != "" && candidate.CategoryName == recipe.CategoryName
+		if ingredientOverlap == 0 && !sameCategory {
+			continue
+		}
+
+		popularity := 0.0
+		if maxVisitations > 0 {
+			popularity = math.Log1p(float64(candidate.VisitationsCount)) / math.Log1p(float64(maxVisitations))
+		}
+
+		candidate.score = 0.6*ingredientOverlap + 0.15*popularity
+		if sameCategory {
+			candidate.score += 0.25
+		}
+		matches = append(matches, candidate)
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > similarRecipesPerRecipe {
+		matches = matches[:similarRecipesPerRecipe]
+	}
+	return
+}
@@ -0,0 +1,218 @@
+package recipes
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"recipes-v2-server/database"
+	"recipes-v2-server/storage"
+	"strings"
+)
+
+// UnmarshalJSON accepts both step objects and the plain text steps the recipes were stored with before steps
+// became structured
+func (step *Step) UnmarshalJSON(data []byte) error {
+	var description string
+	if err := json.Unmarshal(data, &description); err == nil {
+		*step = Step{Description: description}
+		return nil
+	}
+
+	type stepAlias Step
+	var alias stepAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*step = Step(alias)
+	return nil
+}
+
+// Scan parses the steps jsonb column
+func (steps *Steps) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*steps = Steps{}
+		return nil
+	case []byte:
+		return json.Unmarshal(data, steps)
+	case string:
+		return json.Unmarshal([]byte(data), steps)
+	default:
+		return fmt.Errorf("unsupported steps column type %T", value)
+	}
+}
+
+// storedStep keeps the image key of a step in the DB instead of the public URL it is sent to the clients as
+type storedStep struct {
+	Step
+	ImageURL string `json:"imageURL,omitempty"`
+}
+
+// Value serializes the steps for the steps jsonb column
+func (steps Steps) Value() (driver.Value, error) {
+	if steps == nil {
+		return []byte("[]"), nil
+	}
+
+	storedSteps := make([]storedStep, len(steps))
+	for index, step := range steps {
+		storedSteps[index] = storedStep{Step: step, ImageURL: string(step.ImageURL)}
+	}
+	return json.Marshal(storedSteps)
+}
+
+// temperatureLimits are the lowest and highest step temperatures accepted in each unit, from a freezer to a pizza oven
+var temperatureLimits = map[string][2]int{
+	"C": {-50, 300},
+	"F": {-60, 575},
+}
+
+// StepOutOfRangeError describes a step number the recipe does not have
+type StepOutOfRangeError struct {
+	StepNumber int
+	StepsCount int
+}
+
+func (err *StepOutOfRangeError) Error() string {
+	return fmt.Sprintf("step %d is out of range, the recipe has %d steps", err.StepNumber, err.StepsCount)
+}
+
+// ValidateSteps checks the rules of the recipe steps that can not be expressed with validation tags - at least one
+// step, non-negative durations, temperatures within the limits of their unit, 0° included, and ingredient references
+// that point to existing products
+func ValidateSteps(recipe RecipeData) error {
+	if len(recipe.Steps) == 0 {
+		return errors.New("steps: at least one step is required")
+	}
+
+	var products []json.RawMessage
+	if err := json.Unmarshal(recipe.Products, &products); err != nil {
+		return errors.New("products: should be a list")
+	}
+
+	for index, step := range recipe.Steps {
+		if step.DurationSeconds < 0 {
+			return fmt.Errorf("steps[%d].durationSeconds: should not be negative", index)
+		}
+
+		if step.Temperature != nil {
+			if step.Temperature.Value == nil {
+				return fmt.Errorf("steps[%d].temperature.value: is required", index)
+			}
+
+			limits, ok := temperatureLimits[step.Temperature.Unit]
+			if !ok {
+				return fmt.Errorf("steps[%d].temperature.unit: should be C or F", index)
+			}
+			if *step.Temperature.Value < limits[0] || *step.Temperature.Value > limits[1] {
+				return fmt.Errorf("steps[%d].temperature.value: should be between %d and %d", index, limits[0], limits[1])
+			}
+		}
+
+		for _, ingredientIndex := range step.Ingredients {
+			if ingredientIndex < 0 || ingredientIndex >= len(products) {
+				return fmt.Errorf("steps[%d].ingredients: %d does not reference an existing product", index, ingredientIndex)
+			}
+		}
+	}
+	return nil
+}
+
+// GetCookModeStep gets a single step of the recipe together with the products needed for it, translated to the given
+// language when available. Step numbers start from 1.
+func GetCookModeStep(recipeName string, stepNumber int, language string) (cookStep CookModeStep, err error) {
+	var recipe RecipeData
+
+	err = database.GetSingleRecordNamedQuery(
+		&recipe,
+		`SELECT recipe_name, steps, products, language
+				FROM recipes
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		return
+	}
+
+	err = translate(&recipe, language)
+	if err != nil {
+		return
+	}
+
+	if stepNumber < 1 || stepNumber > len(recipe.Steps) {
+		err = &StepOutOfRangeError{StepNumber: stepNumber, StepsCount: len(recipe.Steps)}
+		return
+	}
+
+	var products []json.RawMessage
+	_ = json.Unmarshal(recipe.Products, &products)
+
+	step := recipe.Steps[stepNumber-1]
+
+	cookStep = CookModeStep{
+		RecipeName:  recipe.RecipeName,
+		StepNumber:  stepNumber,
+		TotalSteps:  len(recipe.Steps),
+		Step:        step,
+		Ingredients: []json.RawMessage{},
+		HasPrevious: stepNumber > 1,
+		HasNext:     stepNumber < len(recipe.Steps),
+	}
+
+	for _, ingredientIndex := range step.Ingredients {
+		if ingredientIndex >= 0 && ingredientIndex < len(products) {
+			cookStep.Ingredients = append(cookStep.Ingredients, products[ingredientIndex])
+		}
+	}
+	return
+}
+
+// MigrateStepImageURLsToKeys rewrites the step images stored as absolute URLs under one of the given base URLs to
+// object keys. Returns the number of rewritten recipes.
+func MigrateStepImageURLsToKeys(baseURLs []string) (migrated int, err error) {
+	var patterns []string
+	for _, baseURL := range baseURLs {
+		patterns = append(patterns, "%"+baseURL+"%")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.JobTimeout)
+	defer cancel()
+
+	var recipesSteps []struct {
+		Id    int   `db:"id"`
+		Steps Steps `db:"steps"`
+	}
+	err = database.GetMultipleRecordsNamedQueryContext(
+		ctx,
+		&recipesSteps,
+		`SELECT id, steps FROM recipes WHERE CAST(steps AS TEXT) LIKE ANY (CAST(:patterns AS TEXT[]));`,
+		map[string]interface{}{"patterns": pq.StringArray(patterns)},
+	)
+	if err != nil {
+		return
+	}
+
+	for _, recipe := range recipesSteps {
+		for index, step := range recipe.Steps {
+			for _, baseURL := range baseURLs {
+				if imageURL := string(step.ImageURL); strings.HasPrefix(imageURL, baseURL) && len(imageURL) > len(baseURL) {
+					recipe.Steps[index].ImageURL = storage.ObjectKey(strings.TrimPrefix(imageURL, baseURL))
+				}
+			}
+		}
+
+		_, err = database.ExecuteNamedQueryContext(
+			ctx,
+			`UPDATE recipes SET steps = :steps WHERE id = :id;`,
+			map[string]interface{}{"id": recipe.Id, "steps": recipe.Steps},
+		)
+		if err != nil {
+			return
+		}
+		migrated++
+	}
+	return
+}
@@ -2,18 +2,24 @@ package recipes
 
 import (
 	"encoding/json"
+	"github.com/lib/pq"
+	"recipes-v2-server/internal/prices"
 	"recipes-v2-server/internal/users"
+	"recipes-v2-server/storage"
+	"time"
 )
 
 type ExtendedRecipeInfo struct {
-	ImageURL   string `json:"imageURL" db:"image_url"`
-	RecipeName string `json:"recipeName" db:"recipe_name"`
-	Category   string `json:"category" db:"category"`
+	ImageURL       storage.ObjectKey `json:"imageURL" db:"image_url"`
+	RecipeName     string            `json:"recipeName" db:"recipe_name"`
+	TranslatedName string            `json:"translatedName,omitempty" db:"translated_name"`
+	Category       string            `json:"category" db:"category"`
 }
 
 type BaseRecipeInfo struct {
-	ImageURL   string `json:"imageURL" db:"image_url"`
-	RecipeName string `json:"recipeName" db:"recipe_name" valid:"required"`
+	ImageURL       storage.ObjectKey `json:"imageURL" db:"image_url"`
+	RecipeName     string            `json:"recipeName" db:"recipe_name" valid:"required"`
+	TranslatedName string            `json:"translatedName,omitempty" db:"translated_name"`
 }
 
 type BaseRecipeInfoArray = []BaseRecipeInfo
@@ -31,17 +37,73 @@ type PageData struct {
 }
 
 type RecipeData struct {
-	RecipeName      string          `db:"recipe_name" json:"recipeName" valid:"required,minstringlength(4)"`
-	Products        json.RawMessage `db:"products" json:"products" valid:"required"`
-	Steps           json.RawMessage `db:"steps" json:"steps" valid:"required"`
-	ImageURL        string          `db:"image_url" json:"imageURL" valid:"required,url"`
-	CategoryName    string          `db:"category" json:"category" valid:"required"`
-	Difficulty      string          `db:"difficulty" json:"difficulty" valid:"required"`
-	PreparationTime int             `db:"preparation_time" json:"preparationTime" valid:"required"`
-	Calories        int             `db:"calories" json:"calories"`
-	Protein         int             `db:"protein" json:"protein"`
-	Status          string          `db:"status" json:"-"`
-	users.OwnerData `json:"owner"`
+	RecipeName         string            `db:"recipe_name" json:"recipeName" valid:"required,minstringlength(4)"`
+	Products           json.RawMessage   `db:"products" json:"products" valid:"required"`
+	Steps              Steps             `db:"steps" json:"steps" valid:"required"`
+	ImageURL           storage.ObjectKey `db:"image_url" json:"imageURL" valid:"required"`
+	CategoryName       string            `db:"category" json:"category" valid:"required"`
+	Difficulty         string            `db:"difficulty" json:"difficulty" valid:"required"`
+	PreparationTime    int               `db:"preparation_time" json:"preparationTime" valid:"required"`
+	Calories           int               `db:"calories" json:"calories"`
+	Protein            int               `db:"protein" json:"protein"`
+	Servings           int               `db:"servings" json:"servings" valid:"range(1|100)"`
+	Status             string            `db:"status" json:"-"`
+	Language           string            `db:"language" json:"language" valid:"in(bg|en)"`
+	TimesCooked        int               `db:"times_cooked" json:"timesCooked"`
+	users.OwnerData    `json:"owner"`
+	TranslatedName     string               `db:"-" json:"translatedName,omitempty"`
+	AvailableLanguages pq.StringArray       `db:"-" json:"availableLanguages,omitempty"`
+	Images             []RecipeImage        `db:"-" json:"images,omitempty"`
+	AdaptedFrom        *Attribution         `db:"-" json:"adaptedFrom,omitempty"`
+	Cost               *prices.Estimate     `db:"-" json:"cost,omitempty"`
+	PossibleDuplicates []DuplicateCandidate `db:"-" json:"possibleDuplicates,omitempty"`
+	Notes              []RecipeNote         `db:"-" json:"notes,omitempty"`
+}
+
+type DuplicateCandidate struct {
+	RecipeName string  `json:"recipeName"`
+	Score      float64 `json:"score"`
+}
+
+type Temperature struct {
+	Value *int   `json:"value"`
+	Unit  string `json:"unit" valid:"required,in(C|F)"`
+}
+
+type Step struct {
+	Description     string            `json:"description" valid:"required"`
+	DurationSeconds int               `json:"durationSeconds,omitempty"`
+	Temperature     *Temperature      `json:"temperature,omitempty"`
+	ImageURL        storage.ObjectKey `json:"imageURL,omitempty"`
+	Ingredients     []int             `json:"ingredients,omitempty"`
+}
+
+type Steps []Step
+
+type CookModeStep struct {
+	RecipeName  string            `json:"recipeName"`
+	StepNumber  int               `json:"stepNumber"`
+	TotalSteps  int               `json:"totalSteps"`
+	Step        Step              `json:"step"`
+	Ingredients []json.RawMessage `json:"ingredients"`
+	HasPrevious bool              `json:"hasPrevious"`
+	HasNext     bool              `json:"hasNext"`
+}
+
+type Attribution struct {
+	RecipeName          string `db:"recipe_name" json:"recipeName"`
+	Username            string `db:"username" json:"username"`
+	IsOriginalAvailable bool   `db:"is_original_available" json:"isOriginalAvailable"`
+}
+
+type ForkRequest struct {
+	RecipeName string `json:"recipeName" db:"recipe_name" valid:"minstringlength(4)"`
+}
+
+type ForkInfo struct {
+	RecipeName string            `db:"recipe_name" json:"recipeName"`
+	ImageURL   storage.ObjectKey `db:"image_url" json:"imageURL"`
+	OwnerName  string            `db:"owner_name" json:"ownerName"`
 }
 
 type FavouritesRequest struct {
@@ -55,9 +117,90 @@ type ExtendedRecipeData struct {
 }
 
 type AdminRecipeData struct {
-	RecipeName string `db:"recipe_name" json:"recipeName" valid:"required,minstringlength(4)"`
-	ImageURL   string `db:"image_url" json:"imageURL" valid:"required,url"`
-	Status     string `db:"status" json:"status"`
-	OwnerName  string `db:"owner_name" json:"ownerName" valid:"required"`
-	Id         int    `db:"id" json:"id"`
+	RecipeName           string            `db:"recipe_name" json:"recipeName" valid:"required,minstringlength(4)"`
+	ImageURL             storage.ObjectKey `db:"image_url" json:"imageURL" valid:"required"`
+	Status               string            `db:"status" json:"status"`
+	OwnerName            string            `db:"owner_name" json:"ownerName" valid:"required"`
+	Id                   int               `db:"id" json:"id"`
+	SuspectedDuplicateOf string            `db:"suspected_duplicate_of" json:"suspectedDuplicateOf,omitempty"`
+	DuplicateScore       float64           `db:"duplicate_score" json:"duplicateScore,omitempty"`
+	PublishAt            *time.Time        `db:"publish_at" json:"publishAt,omitempty"`
+}
+
+type ScheduledRecipe struct {
+	Id          int               `db:"id" json:"id"`
+	RecipeName  string            `db:"recipe_name" json:"recipeName"`
+	ImageURL    storage.ObjectKey `db:"image_url" json:"imageURL"`
+	PublishAt   time.Time         `db:"publish_at" json:"publishAt"`
+	OwnerId     int               `db:"owner_id" json:"-"`
+	OwnerName   string            `db:"owner_name" json:"ownerName"`
+	OwnerAvatar storage.ObjectKey `db:"owner_avatar" json:"-"`
+}
+
+type DeletedRecipe struct {
+	Id         int               `db:"id" json:"id"`
+	RecipeName string            `db:"recipe_name" json:"recipeName"`
+	ImageURL   storage.ObjectKey `db:"image_url" json:"imageURL"`
+	Status     string            `db:"status" json:"status"`
+	OwnerName  string            `db:"owner_name" json:"ownerName"`
+	DeletedAt  time.Time         `db:"deleted_at" json:"deletedAt"`
+}
+
+type RecipeScheduleRequest struct {
+	PublishAt string `json:"publishAt" form:"publishAt" valid:"required,rfc3339"`
+}
+
+type similarityCandidate struct {
+	Id               int             `db:"id"`
+	RecipeName       string          `db:"recipe_name"`
+	CategoryName     string          `db:"category"`
+	Products         json.RawMessage `db:"products"`
+	VisitationsCount int             `db:"visitations_count"`
+	ingredients      map[string]struct{}
+	score            float64
+}
+
+type RecipeImage struct {
+	Id       int               `db:"id" json:"id"`
+	ImageURL storage.ObjectKey `db:"image_url" json:"imageURL"`
+	AltText  string            `db:"alt_text" json:"altText"`
+	Position int               `db:"position" json:"position"`
+	IsCover  bool              `db:"is_cover" json:"isCover"`
+}
+
+type RecipeImageEditRequest struct {
+	AltText string `json:"altText" form:"altText" db:"alt_text" valid:"maxstringlength(255)"`
+	IsCover bool   `json:"isCover" form:"isCover" db:"is_cover"`
+}
+
+type RecipeImagesOrderRequest struct {
+	ImageIds []int `json:"imageIds"`
+}
+
+type RecipeTranslation struct {
+	Language   string          `db:"language" json:"language"`
+	RecipeName string          `db:"recipe_name" json:"recipeName" valid:"required,minstringlength(4)"`
+	Products   json.RawMessage `db:"products" json:"products" valid:"required"`
+	Steps      pq.StringArray  `db:"steps" json:"steps"`
+}
+
+type RecipeNote struct {
+	Id              int       `json:"id" db:"id"`
+	RecipeName      string    `json:"recipeName" db:"recipe_name"`
+	StepNumber      *int      `json:"stepNumber,omitempty" db:"step_number"`
+	IngredientIndex *int      `json:"ingredientIndex,omitempty" db:"ingredient_index"`
+	Content         string    `json:"content" db:"content"`
+	CreatedAt       time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type RecipeNoteRequest struct {
+	Content         string `json:"content" valid:"required,maxstringlength(2000)"`
+	StepNumber      *int   `json:"stepNumber"`
+	IngredientIndex *int   `json:"ingredientIndex"`
+}
+
+type CostFilter struct {
+	MaxCost  float64
+	Currency string
 }
@@ -0,0 +1,29 @@
+package recipes
+
+import (
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/substitutions"
+)
+
+// GetSubstitutions suggests substitutes for the products of the recipe from the substitution knowledge base. When
+// diets are given, only the substitutes that fit all of them are suggested.
+func GetSubstitutions(recipeName string, diets []string) (suggestions []substitutions.ProductSubstitutions, err error) {
+	var recipe RecipeData
+
+	err = database.GetSingleRecordNamedQuery(
+		&recipe,
+		`SELECT recipe_name, products
+				FROM recipes
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		return
+	}
+
+	knowledgeBase, err := substitutions.GetAll()
+	if err != nil {
+		return
+	}
+	return substitutions.Suggest(productLines(recipe.Products), diets, knowledgeBase), nil
+}
@@ -0,0 +1,212 @@
+package recipes
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/text/language"
+	"recipes-v2-server/database"
+)
+
+// OriginalLanguage is the language of the recipes created without one
+const OriginalLanguage = "bg"
+
+var languageMatcher = language.NewMatcher([]language.Tag{language.Bulgarian, language.English})
+
+var supportedLanguages = []string{"bg", "en"}
+
+// MatchLanguage picks the supported language the recipes are read in. The lang query parameter takes precedence over
+// the Accept-Language header. Returns an empty string when neither of them asks for a supported language, so the
+// recipes are returned in their original language.
+func MatchLanguage(lang, acceptLanguage string) string {
+	if IsSupportedLanguage(lang) {
+		return lang
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+
+	_, index, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No {
+		return ""
+	}
+	return supportedLanguages[index]
+}
+
+// IsSupportedLanguage checks if recipes can be translated to the given language
+func IsSupportedLanguage(lang string) bool {
+	for _, supportedLanguage := range supportedLanguages {
+		if lang == supportedLanguage {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTranslations gets the translations of the recipe
+func GetTranslations(recipeName string) (translations []RecipeTranslation, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&translations,
+		`SELECT recipe_translations.language,
+					   recipe_translations.recipe_name,
+					   recipe_translations.products,
+					   recipe_translations.steps
+				FROM recipe_translations
+						 JOIN recipes ON recipes.id = recipe_translations.recipe_id
+				WHERE recipes.recipe_name = :recipe_name AND recipes.deleted_at IS NULL
+				ORDER BY recipe_translations.language;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	return
+}
+
+// SaveTranslation adds or replaces the translation of the recipe in the given language. Only the texts are
+// translated, so the translation should have as many products and steps as the recipe. The durations, temperatures,
+// images and ingredients of the steps are taken from the recipe.
+func SaveTranslation(recipeName string, translation RecipeTranslation) (saved RecipeTranslation, err error) {
+	var recipe RecipeData
+
+	err = database.GetSingleRecordNamedQuery(
+		&recipe,
+		`SELECT recipe_name, products, steps, language
+				FROM recipes
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL;`,
+		map[string]interface{}{"recipe_name": recipeName},
+	)
+	if err != nil {
+		return
+	}
+
+	err = validateTranslation(recipe, translation)
+	if err != nil {
+		return
+	}
+
+	err = database.GetSingleRecordNamedQuery(
+		&saved,
+		`INSERT INTO recipe_translations (recipe_id, language, recipe_name, products, steps, created_at, updated_at)
+				SELECT id, :language, :translated_name, :products, :steps, NOW(), NOW()
+				FROM recipes
+				WHERE recipe_name = :recipe_name
+				ON CONFLICT (recipe_id, language) DO UPDATE SET recipe_name = excluded.recipe_name,
+																products    = excluded.products,
+																steps       = excluded.steps,
+																updated_at  = excluded.updated_at
+				RETURNING language, recipe_name, products, steps;`,
+		map[string]interface{}{
+			"recipe_name":     recipeName,
+			"language":        translation.Language,
+			"translated_name": translation.RecipeName,
+			"products":        translation.Products,
+			"steps":           translation.Steps,
+		},
+	)
+	return
+}
+
+// DeleteTranslation removes the translation of the recipe in the given language
+func DeleteTranslation(recipeName, language string) (err error) {
+	var deletedLanguage string
+
+	err = database.GetSingleRecordNamedQuery(
+		&deletedLanguage,
+		`DELETE
+				FROM recipe_translations
+				WHERE language = :language
+				  AND recipe_id = (SELECT id FROM recipes WHERE recipe_name = :recipe_name)
+				RETURNING language;`,
+		map[string]interface{}{"recipe_name": recipeName, "language": language},
+	)
+	return
+}
+
+// InvalidTranslationError describes a translation that does not fit the recipe it translates
+type InvalidTranslationError struct {
+	Message string
+}
+
+func (err *InvalidTranslationError) Error() string {
+	return err.Message
+}
+
+func validateTranslation(recipe RecipeData, translation RecipeTranslation) error {
+	if translation.Language == recipe.Language {
+		return &InvalidTranslationError{Message: fmt.Sprintf("language: the recipe is originally in %s", recipe.Language)}
+	}
+
+	var products, translatedProducts []json.RawMessage
+	if err := json.Unmarshal(translation.Products, &translatedProducts); err != nil {
+		return &InvalidTranslationError{Message: "products: should be a list"}
+	}
+	if err := json.Unmarshal(recipe.Products, &products); err == nil && len(products) != len(translatedProducts) {
+		return &InvalidTranslationError{Message: fmt.Sprintf("products: should have %d items like the recipe", len(products))}
+	}
+
+	if len(translation.Steps) != len(recipe.Steps) {
+		return &InvalidTranslationError{Message: fmt.Sprintf("steps: should have %d items like the recipe", len(recipe.Steps))}
+	}
+	for index, step := range translation.Steps {
+		if step == "" {
+			return &InvalidTranslationError{Message: fmt.Sprintf("steps[%d]: should not be empty", index)}
+		}
+	}
+	return nil
+}
+
+// translate replaces the texts of the recipe with its translation in the given language. Recipes without one are
+// left in their original language.
+func translate(recipe *RecipeData, language string) (err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&recipe.AvailableLanguages,
+		`SELECT ARRAY(SELECT language
+					  FROM recipe_translations
+							   JOIN recipes ON recipes.id = recipe_translations.recipe_id
+					  WHERE recipes.recipe_name = :recipe_name
+					  ORDER BY language);`,
+		map[string]interface{}{"recipe_name": recipe.RecipeName},
+	)
+	if err != nil || language == "" || language == recipe.Language {
+		return
+	}
+
+	var translation RecipeTranslation
+
+	err = database.GetSingleRecordNamedQuery(
+		&translation,
+		`SELECT recipe_translations.language,
+					   recipe_translations.recipe_name,
+					   recipe_translations.products,
+					   recipe_translations.steps
+				FROM recipe_translations
+						 JOIN recipes ON recipes.id = recipe_translations.recipe_id
+				WHERE recipes.recipe_name = :recipe_name
+				  AND recipe_translations.language = :language;`,
+		map[string]interface{}{"recipe_name": recipe.RecipeName, "language": language},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return
+	}
+
+	// the recipe may have been edited since it was translated, so the products and the steps are replaced only when
+	// their count still matches the translation
+	var products, translatedProducts []json.RawMessage
+	if json.Unmarshal(recipe.Products, &products) == nil && json.Unmarshal(translation.Products, &translatedProducts) == nil &&
+		len(products) == len(translatedProducts) {
+		recipe.Products = translation.Products
+	}
+	if len(recipe.Steps) == len(translation.Steps) {
+		for index := range recipe.Steps {
+			recipe.Steps[index].Description = translation.Steps[index]
+		}
+	}
+
+	recipe.TranslatedName = translation.RecipeName
+	recipe.Language = translation.Language
+	return
+}
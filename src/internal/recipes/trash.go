@@ -0,0 +1,92 @@
+package recipes
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"recipes-v2-server/database"
+)
+
+// GetDeleted gets the recipes in the trash, the most recently deleted first
+func GetDeleted() (recipes []DeletedRecipe, err error) {
+	err = database.GetMultipleRecords(
+		&recipes,
+		`SELECT recipes.id,
+					   recipes.recipe_name,
+					   recipes.image_url,
+					   recipes.status,
+					   COALESCE(users.username, '') AS owner_name,
+					   recipes.deleted_at
+				FROM recipes
+						 LEFT JOIN users ON users.id = recipes.owner_id
+				WHERE recipes.deleted_at IS NOT NULL
+				ORDER BY recipes.deleted_at DESC;`,
+	)
+	return
+}
+
+// Restore takes a recipe out of the trash together with its comments, favourites and images
+func Restore(id int) (err error) {
+	var restoredId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&restoredId,
+		`UPDATE recipes SET deleted_at = NULL WHERE id = :id AND deleted_at IS NOT NULL RETURNING id;`,
+		map[string]interface{}{"id": id},
+	)
+	return
+}
+
+// PurgeDeleted permanently deletes the recipes that have been in the trash for longer than the given number of days.
+// A failing recipe does not stop the purge of the rest, the errors are joined. Returns the number of purged recipes.
+func PurgeDeleted(retentionDays int) (purged int, err error) {
+	var ids []int
+
+	err = database.GetMultipleRecordsNamedQuery(
+		&ids,
+		`SELECT id FROM recipes WHERE deleted_at < NOW() - MAKE_INTERVAL(days => :retention_days);`,
+		map[string]interface{}{"retention_days": retentionDays},
+	)
+	if err != nil {
+		return
+	}
+
+	for _, id := range ids {
+		if purgeErr := purge(id); purgeErr != nil {
+			err = errors.Join(err, fmt.Errorf("purging recipe %d: %w", id, purgeErr))
+			continue
+		}
+		purged++
+	}
+	return
+}
+
+// purge deletes a recipe and removes its image, gallery, step and cook log images from storage unless a fork still
+// uses them
+func purge(id int) (err error) {
+	var imageKeys pq.StringArray
+
+	err = database.GetSingleRecordNamedQuery(
+		&imageKeys,
+		`WITH delete_favourites AS (DELETE FROM users_favourites WHERE favourites_id = :id),
+     				 delete_comments AS (DELETE FROM comments WHERE target_recipe_id = :id),
+					 delete_images AS (DELETE FROM recipe_images WHERE recipe_id = :id RETURNING image_url),
+					 delete_cook_logs AS (DELETE FROM cook_logs WHERE recipe_id = :id RETURNING photo_url)
+				
+				DELETE
+				FROM recipes
+				WHERE id = :id
+				RETURNING ARRAY(SELECT image_url FROM delete_images) ||
+						  ARRAY(SELECT photo_url FROM delete_cook_logs) ||
+						  ARRAY(SELECT step ->> 'imageURL'
+								FROM JSON_ARRAY_ELEMENTS(CAST(recipes.steps AS JSON)) AS step
+								WHERE JSON_TYPEOF(step) = 'object' AND step ->> 'imageURL' IS NOT NULL) ||
+						  COALESCE(recipes.image_url, '');`,
+		map[string]interface{}{"id": id},
+	)
+	if err != nil {
+		return
+	}
+
+	return deleteUnusedImages(imageKeys)
+}
@@ -0,0 +1,93 @@
+package recipes
+
+import (
+	"recipes-v2-server/database"
+	"strconv"
+)
+
+const defaultTrendingHalfLifeDays = 7
+
+var trendingHalfLifeDays = defaultTrendingHalfLifeDays
+
+// GetTrendingHalfLife retrieves the trending half-life in days from the config and stores it in memory. Falls back
+// to a week when it is missing or invalid.
+func GetTrendingHalfLife(halfLifeDays string) {
+	asNumber, err := strconv.Atoi(halfLifeDays)
+	if err != nil || asNumber < 1 {
+		trendingHalfLifeDays = defaultTrendingHalfLifeDays
+		return
+	}
+	trendingHalfLifeDays = asNumber
+}
+
+// GetTrending gets the approved recipes with the highest trending score
+func GetTrending(limit int) (recipes []ExtendedRecipeInfo, err error) {
+	err = database.GetMultipleRecordsNamedQuery(
+		&recipes,
+		`SELECT recipe_name,
+					   image_url,
+					   category
+				FROM recipe_trending_scores
+						 JOIN recipes ON recipes.id = recipe_trending_scores.recipe_id
+				WHERE status = 'APPROVED' AND deleted_at IS NULL
+				ORDER BY score DESC
+				LIMIT :limit;`,
+		map[string]interface{}{"limit": limit},
+	)
+	return
+}
+
+// RefreshTrendingScores recalculates the trending score of the approved recipes from their daily views. Every view
+// loses half of its weight with each half-life that passed since the day it happened. Views older than four
+// half-lives are ignored since their weight is negligible.
+func RefreshTrendingScores() (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`WITH delete_previous AS (DELETE FROM recipe_trending_scores)
+
+				INSERT
+				INTO recipe_trending_scores (recipe_id, score, computed_at)
+				SELECT recipe_id,
+					   SUM(views * POWER(0.5, (CURRENT_DATE - day) / CAST(:half_life AS FLOAT8))),
+					   NOW()
+				FROM recipe_daily_views
+						 JOIN recipes ON recipes.id = recipe_daily_views.recipe_id
+				WHERE status = 'APPROVED'
+				  AND deleted_at IS NULL
+				  AND day > CURRENT_DATE - 4 * CAST(:half_life AS INT)
+				GROUP BY recipe_id;`,
+		map[string]interface{}{"half_life": trendingHalfLifeDays},
+	)
+	return
+}
+
+// SyncVisitationsCount adds the recipe views counted since the last sync to the visitations count of the recipes
+func SyncVisitationsCount() (err error) {
+	_, err = database.ExecuteQuery(
+		`WITH previously_synced AS (SELECT recipe_id, day, synced_views
+										 FROM recipe_daily_views
+										 WHERE synced_views < views
+											 FOR UPDATE),
+
+					 synced AS (UPDATE recipe_daily_views
+						 SET synced_views = recipe_daily_views.views
+						 FROM previously_synced
+						 WHERE recipe_daily_views.recipe_id = previously_synced.recipe_id
+							 AND recipe_daily_views.day = previously_synced.day
+						 RETURNING recipe_daily_views.recipe_id,
+							 recipe_daily_views.views - previously_synced.synced_views AS new_views)
+
+				UPDATE recipes
+				SET visitations_count = visitations_count + pending.new_views
+				FROM (SELECT recipe_id, SUM(new_views) AS new_views FROM synced GROUP BY recipe_id) AS pending
+				WHERE recipes.id = pending.recipe_id;`,
+	)
+	return
+}
+
+// CleanUpViewVisitors removes the visitors which last recipe view is out of the deduplication window
+func CleanUpViewVisitors() (err error) {
+	_, err = database.ExecuteQuery(
+		`DELETE FROM recipe_view_visitors WHERE last_viewed_at < NOW() - INTERVAL '1 day';`,
+	)
+	return
+}
@@ -10,18 +10,25 @@ func UserSearch(query string) (results pq.StringArray, err error) {
 	filter := "%" + query + "%"
 	err = database.GetSingleRecordNamedQuery(
 		&results,
-		`SELECT ARRAY(SELECT username FROM users WHERE username LIKE :search);`,
+		`SELECT ARRAY(SELECT username FROM users WHERE username LIKE :search AND deleted_at IS NULL);`,
 		map[string]interface{}{"search": filter},
 	)
 	return
 }
 
-// RecipesSearch searches recipes by name and returns them
+// RecipesSearch searches recipes by their original or translated names and returns their original names
 func RecipesSearch(query string) (results pq.StringArray, err error) {
 	filter := "%" + query + "%"
 	err = database.GetSingleRecordNamedQuery(
 		&results,
-		`SELECT ARRAY(SELECT recipe_name FROM recipes WHERE recipe_name LIKE :search);`,
+		`SELECT ARRAY(SELECT recipe_name
+					  FROM recipes
+					  WHERE (recipe_name LIKE :search
+						  OR EXISTS(SELECT 1
+									FROM recipe_translations
+									WHERE recipe_id = recipes.id
+									  AND recipe_translations.recipe_name LIKE :search))
+						AND deleted_at IS NULL);`,
 		map[string]interface{}{"search": filter},
 	)
 	return
@@ -32,7 +39,7 @@ func CommentsSearch(query string) (results pq.StringArray, err error) {
 	filter := "%" + query + "%"
 	err = database.GetSingleRecordNamedQuery(
 		&results,
-		`SELECT ARRAY(SELECT content FROM comments WHERE content LIKE :search);`,
+		`SELECT ARRAY(SELECT content FROM comments WHERE content LIKE :search AND deleted_at IS NULL);`,
 		map[string]interface{}{"search": filter},
 	)
 	return
@@ -43,11 +50,20 @@ func Global(query string) (results []GlobalSearch, err error) {
 	filter := "%" + query + "%"
 	err = database.GetMultipleRecordsNamedQuery(
 		&results,
-		`WITH users_search AS (SELECT 'users' AS collection_name, username AS content FROM users WHERE username LIKE :search),
+		`WITH users_search AS (SELECT 'users' AS collection_name, username AS content
+									  FROM users
+									  WHERE username LIKE :search AND deleted_at IS NULL),
 					 recipes_search AS (SELECT 'recipes' AS collection_name, recipe_name AS content
 										FROM recipes
-										WHERE recipe_name LIKE :search),
-					 comments_search AS (SELECT 'comments' AS collection_name, content FROM comments WHERE content LIKE :search)
+										WHERE (recipe_name LIKE :search
+											OR EXISTS(SELECT 1
+													  FROM recipe_translations
+													  WHERE recipe_id = recipes.id
+														AND recipe_translations.recipe_name LIKE :search))
+										  AND deleted_at IS NULL),
+					 comments_search AS (SELECT 'comments' AS collection_name, content
+										 FROM comments
+										 WHERE content LIKE :search AND deleted_at IS NULL)
 				
 				SELECT collection_name, ARRAY_AGG(content) AS results
 				FROM (SELECT *
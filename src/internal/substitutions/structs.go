@@ -0,0 +1,30 @@
+package substitutions
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+type Substitution struct {
+	Id          int            `json:"id" db:"id"`
+	Ingredient  string         `json:"ingredient" db:"ingredient"`
+	Substitute  string         `json:"substitute" db:"substitute"`
+	Ratio       float64        `json:"ratio" db:"ratio"`
+	Notes       string         `json:"notes" db:"notes"`
+	DietaryTags pq.StringArray `json:"dietaryTags" db:"dietary_tags"`
+	UpdatedAt   time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type SubstitutionRequest struct {
+	Ingredient  string         `json:"ingredient" db:"ingredient" valid:"required,minstringlength(2)"`
+	Substitute  string         `json:"substitute" db:"substitute" valid:"required,minstringlength(2)"`
+	Ratio       float64        `json:"ratio" db:"ratio"`
+	Notes       string         `json:"notes" db:"notes" valid:"maxstringlength(1000)"`
+	DietaryTags pq.StringArray `json:"dietaryTags" db:"dietary_tags"`
+}
+
+type ProductSubstitutions struct {
+	Index         int            `json:"index"`
+	Product       string         `json:"product"`
+	Substitutions []Substitution `json:"substitutions"`
+}
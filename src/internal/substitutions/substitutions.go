@@ -0,0 +1,109 @@
+package substitutions
+
+import (
+	"github.com/lib/pq"
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/prices"
+	"slices"
+	"strings"
+)
+
+// Diets are the dietary tags a substitute can be marked with and the recipe substitutions can be filtered by
+var Diets = []string{"vegan", "vegetarian", "gluten-free", "dairy-free", "egg-free", "nut-free"}
+
+// UnknownDiets returns the given diets that are not one of the supported dietary tags
+func UnknownDiets(diets []string) (unknown []string) {
+	for _, diet := range diets {
+		if !slices.Contains(Diets, diet) {
+			unknown = append(unknown, diet)
+		}
+	}
+	return
+}
+
+// GetAll gets the substitution knowledge base ordered by ingredient
+func GetAll() (substitutions []Substitution, err error) {
+	err = database.GetMultipleRecords(
+		&substitutions,
+		`SELECT id, ingredient, substitute, ratio, notes, dietary_tags, updated_at
+				FROM ingredient_substitutions
+				ORDER BY ingredient, substitute;`,
+	)
+	return
+}
+
+// Save adds a substitute of an ingredient or replaces its ratio, notes and dietary tags when the ingredient already
+// has it. A missing ratio means the substitute is used in the same amount.
+func Save(request SubstitutionRequest) (substitution Substitution, err error) {
+	request.Ingredient = strings.ToLower(strings.TrimSpace(request.Ingredient))
+	request.Substitute = strings.TrimSpace(request.Substitute)
+	if request.Ratio == 0 {
+		request.Ratio = 1
+	}
+	if request.DietaryTags == nil {
+		request.DietaryTags = pq.StringArray{}
+	}
+
+	err = database.GetSingleRecordNamedQuery(
+		&substitution,
+		`INSERT INTO ingredient_substitutions (ingredient, substitute, ratio, notes, dietary_tags, updated_at)
+				VALUES (:ingredient, :substitute, :ratio, :notes, CAST(:dietary_tags AS TEXT[]), NOW())
+				ON CONFLICT (ingredient, substitute) DO UPDATE SET ratio        = excluded.ratio,
+																   notes        = excluded.notes,
+																   dietary_tags = excluded.dietary_tags,
+																   updated_at   = excluded.updated_at
+				RETURNING id, ingredient, substitute, ratio, notes, dietary_tags, updated_at;`,
+		request,
+	)
+	return
+}
+
+// Delete removes a substitute from the knowledge base
+func Delete(id int) (err error) {
+	var deletedId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&deletedId,
+		`DELETE FROM ingredient_substitutions WHERE id = :id RETURNING id;`,
+		map[string]interface{}{"id": id},
+	)
+	return
+}
+
+// Suggest finds the substitutes of every product in the knowledge base. A product is matched to the ingredient with
+// the most words that appear in it, the same way it is matched to its price. When diets are given, only the
+// substitutes tagged with all of them are suggested. Products without substitutes are returned with an empty list.
+func Suggest(products []string, diets []string, knowledgeBase []Substitution) (suggestions []ProductSubstitutions) {
+	var ingredients []string
+	substitutesOf := map[string][]Substitution{}
+	for _, substitution := range knowledgeBase {
+		if _, isKnown := substitutesOf[substitution.Ingredient]; !isKnown {
+			ingredients = append(ingredients, substitution.Ingredient)
+		}
+		substitutesOf[substitution.Ingredient] = append(substitutesOf[substitution.Ingredient], substitution)
+	}
+
+	suggestions = []ProductSubstitutions{}
+	for index, product := range products {
+		suggestion := ProductSubstitutions{Index: index, Product: product, Substitutions: []Substitution{}}
+		// the diets are applied after matching, so a product is never offered the substitutes of a shorter ingredient
+		if match, found := prices.MatchIngredient(product, ingredients); found {
+			for _, substitution := range substitutesOf[ingredients[match]] {
+				if hasAllDiets(substitution, diets) {
+					suggestion.Substitutions = append(suggestion.Substitutions, substitution)
+				}
+			}
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return
+}
+
+func hasAllDiets(substitution Substitution, diets []string) bool {
+	for _, diet := range diets {
+		if !slices.Contains(substitution.DietaryTags, diet) {
+			return false
+		}
+	}
+	return true
+}
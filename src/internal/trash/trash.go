@@ -0,0 +1,34 @@
+package trash
+
+import (
+	"errors"
+	"recipes-v2-server/internal/comments"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/internal/users"
+	"strconv"
+)
+
+const defaultRetentionDays = 30
+
+var retentionDays = defaultRetentionDays
+
+// GetRetentionPeriod retrieves the days deleted items are kept in the trash for from the config and stores it in
+// memory. Falls back to 30 days when it is missing or invalid.
+func GetRetentionPeriod(days string) {
+	asNumber, err := strconv.Atoi(days)
+	if err != nil || asNumber < 1 {
+		retentionDays = defaultRetentionDays
+		return
+	}
+	retentionDays = asNumber
+}
+
+// Purge permanently deletes the comments, recipes and users that have been in the trash for longer than the
+// retention period, together with their stored images. Users go last, so their purged recipes are not transferred.
+func Purge() (err error) {
+	_, commentsErr := comments.PurgeDeleted(retentionDays)
+	_, recipesErr := recipes.PurgeDeleted(retentionDays)
+	_, usersErr := users.PurgeDeleted(retentionDays)
+
+	return errors.Join(commentsErr, recipesErr, usersErr)
+}
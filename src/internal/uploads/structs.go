@@ -0,0 +1,45 @@
+package uploads
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+type PresignRequest struct {
+	Kind        string `json:"kind" valid:"required,in(avatar|cover|recipe)"`
+	ContentType string `json:"contentType" valid:"required,in(image/jpeg|image/png|image/gif)"`
+	Size        int64  `json:"size" valid:"required"`
+}
+
+type PresignedUpload struct {
+	Key       string            `json:"key" db:"key"`
+	UploadURL string            `json:"uploadURL" db:"-"`
+	Method    string            `json:"method" db:"-"`
+	Headers   map[string]string `json:"headers" db:"-"`
+	ExpiresAt time.Time         `json:"expiresAt" db:"expires_at"`
+}
+
+type ConfirmRequest struct {
+	Key  string `json:"key" valid:"required"`
+	Kind string `json:"kind" valid:"required,in(avatar|cover|recipe)"`
+}
+
+type ConfirmRecipeImageRequest struct {
+	Key     string `json:"key" valid:"required"`
+	AltText string `json:"altText" valid:"maxstringlength(255)"`
+}
+
+type pendingUpload struct {
+	Key  string `db:"key"`
+	Size int64  `db:"size"`
+}
+
+type TusUpload struct {
+	Id        string         `db:"id"`
+	Key       string         `db:"key"`
+	Kind      string         `db:"kind"`
+	Length    int64          `db:"length"`
+	Offset    int64          `db:"upload_offset"`
+	ChunkKeys pq.StringArray `db:"chunk_keys"`
+	ExpiresAt time.Time      `db:"expires_at"`
+}
@@ -0,0 +1,240 @@
+package uploads
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/storage"
+	"recipes-v2-server/utils"
+	"time"
+)
+
+// TusVersion is the version of the tus resumable upload protocol the server implements
+const TusVersion = "1.0.0"
+
+const tusUploadExpiry = 24 * time.Hour
+
+var (
+	// ErrTusOffsetMismatch is returned when the client sends bytes for another offset than the one the upload is at
+	ErrTusOffsetMismatch = errors.New("the upload offset does not match the received bytes")
+	// ErrTusLengthExceeded is returned when the client sends more bytes than the length it declared
+	ErrTusLengthExceeded = errors.New("the upload is longer than its declared length")
+	// ErrTusInterrupted is returned when the request body broke off. The bytes received until then are kept.
+	ErrTusInterrupted = errors.New("the upload was interrupted")
+)
+
+// MaxUploadSize is the largest direct or resumable upload the clients can start, the limit of the largest image kind
+func MaxUploadSize() (maxSize int64) {
+	for _, kind := range kinds {
+		maxSize = max(maxSize, kind.MaxBytes)
+	}
+	return
+}
+
+// CreateTus starts a resumable upload of an image of the given kind. Once all of its bytes are received it becomes a
+// pending upload, confirmed the same way as the presigned ones.
+func CreateTus(userId int, kind string, length int64) (upload TusUpload, err error) {
+	imageKind, isKnown := kinds[kind]
+	if !isKnown {
+		err = &images.ValidationError{Code: "unsupported_kind", Message: "the upload kind should be avatar, cover or recipe"}
+		return
+	}
+	if length <= 0 || length > imageKind.MaxBytes {
+		err = fileTooLargeError(imageKind)
+		return
+	}
+
+	randomPart := make([]byte, 16)
+	if _, err = rand.Read(randomPart); err != nil {
+		return
+	}
+	id := fmt.Sprintf("%d-%s", userId, hex.EncodeToString(randomPart))
+
+	err = database.GetSingleRecordNamedQuery(
+		&upload,
+		`INSERT INTO tus_uploads (id, key, user_id, kind, length, upload_offset, chunk_keys, created_at, expires_at)
+				VALUES (:id, :key, :user_id, :kind, :length, 0, '{}', NOW(), NOW() + CAST(:expiry AS INTERVAL))
+				RETURNING id, key, kind, length, upload_offset, chunk_keys, expires_at;`,
+		map[string]interface{}{
+			"id":      id,
+			"key":     "pending-uploads/" + id,
+			"user_id": userId,
+			"kind":    kind,
+			"length":  length,
+			"expiry":  tusUploadExpiry.String(),
+		},
+	)
+	return
+}
+
+// GetTus gets the progress of a resumable upload of the user
+func GetTus(userId int, id string) (upload TusUpload, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&upload,
+		`SELECT id, key, kind, length, upload_offset, chunk_keys, expires_at
+				FROM tus_uploads
+				WHERE id = :id
+				  AND user_id = :user_id
+				  AND expires_at > NOW();`,
+		map[string]interface{}{"id": id, "user_id": userId},
+	)
+	return
+}
+
+// WriteTusChunk stores the bytes sent for the given offset of a resumable upload. The bytes received before a broken
+// connection are kept, so the client resumes after them. The upload is completed with its last bytes.
+func WriteTusChunk(userId int, id string, offset int64, body io.Reader) (upload TusUpload, err error) {
+	upload, err = GetTus(userId, id)
+	if err != nil {
+		return
+	}
+	if offset != upload.Offset {
+		err = ErrTusOffsetMismatch
+		return
+	}
+
+	remaining := upload.Length - upload.Offset
+	data, readErr := io.ReadAll(io.LimitReader(body, remaining+1))
+	if int64(len(data)) > remaining {
+		err = ErrTusLengthExceeded
+		return
+	}
+
+	if len(data) > 0 {
+		upload, err = appendTusChunk(upload, data)
+		if err != nil {
+			return
+		}
+	}
+
+	if upload.Offset == upload.Length && len(upload.ChunkKeys) > 0 {
+		err = completeTus(userId, upload)
+		if err != nil {
+			return
+		}
+	}
+
+	if readErr != nil {
+		err = fmt.Errorf("%w - %w", ErrTusInterrupted, readErr)
+	}
+	return
+}
+
+// TerminateTus cancels a resumable upload of the user and removes the bytes received so far
+func TerminateTus(userId int, id string) (err error) {
+	var upload TusUpload
+
+	err = database.GetSingleRecordNamedQuery(
+		&upload,
+		`DELETE FROM tus_uploads WHERE id = :id AND user_id = :user_id RETURNING id, chunk_keys;`,
+		map[string]interface{}{"id": id, "user_id": userId},
+	)
+	if err != nil {
+		return
+	}
+
+	deleteTusChunks(upload.ChunkKeys)
+	return
+}
+
+func appendTusChunk(upload TusUpload, data []byte) (appended TusUpload, err error) {
+	// chunks written for the same offset by concurrent requests must not overwrite each other
+	chunkKey := fmt.Sprintf("tus-uploads/%s/%012d-%d", upload.Id, upload.Offset, time.Now().UnixNano())
+
+	err = storage.Put(chunkKey, bytes.NewReader(data), "application/octet-stream", "")
+	if err != nil {
+		return
+	}
+
+	err = database.GetSingleRecordNamedQuery(
+		&appended,
+		`UPDATE tus_uploads
+				SET upload_offset = upload_offset + :size,
+					chunk_keys    = ARRAY_APPEND(chunk_keys, CAST(:chunk_key AS TEXT))
+				WHERE id = :id
+				  AND upload_offset = :offset
+				RETURNING id, key, kind, length, upload_offset, chunk_keys, expires_at;`,
+		map[string]interface{}{"id": upload.Id, "offset": upload.Offset, "size": len(data), "chunk_key": chunkKey},
+	)
+	if err != nil && err.Error() == "sql: no rows in result set" {
+		err = ErrTusOffsetMismatch
+	}
+	return
+}
+
+// completeTus joins the chunks of the upload into the pending upload the client confirms
+func completeTus(userId int, upload TusUpload) (err error) {
+	var content bytes.Buffer
+	for _, chunkKey := range upload.ChunkKeys {
+		err = copyTusChunk(&content, chunkKey)
+		if err != nil {
+			return
+		}
+	}
+	if int64(content.Len()) != upload.Length {
+		return fmt.Errorf("the chunks of upload %s have %d bytes instead of %d", upload.Id, content.Len(), upload.Length)
+	}
+
+	format, err := images.SniffFormat(content.Bytes())
+	if err != nil {
+		return
+	}
+
+	err = storage.Put(upload.Key, bytes.NewReader(content.Bytes()), "image/"+format, "")
+	if err != nil {
+		return
+	}
+
+	_, err = database.ExecuteNamedQuery(
+		`WITH completed AS (UPDATE tus_uploads SET chunk_keys = '{}' WHERE id = :id)
+
+				INSERT
+				INTO pending_uploads (key, user_id, kind, content_type, size, created_at, expires_at)
+				VALUES (:key, :user_id, :kind, :content_type, :size, NOW(), NOW() + CAST(:expiry AS INTERVAL))
+				ON CONFLICT (key) DO NOTHING;`,
+		map[string]interface{}{
+			"id":           upload.Id,
+			"key":          upload.Key,
+			"user_id":      userId,
+			"kind":         upload.Kind,
+			"content_type": "image/" + format,
+			"size":         upload.Length,
+			"expiry":       presignedUploadExpiry.String(),
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	deleteTusChunks(upload.ChunkKeys)
+	return
+}
+
+func copyTusChunk(destination io.Writer, chunkKey string) (err error) {
+	chunk, err := storage.Get(chunkKey)
+	if err != nil {
+		return
+	}
+	defer chunk.Close()
+
+	_, err = io.Copy(destination, chunk)
+	return
+}
+
+// deleteTusChunks only logs a failed clean up, the chunks left behind are removed by the orphans job
+func deleteTusChunks(chunkKeys []string) {
+	for _, chunkKey := range chunkKeys {
+		if err := storage.Delete(chunkKey); err != nil {
+			utils.
+				GetLogger().
+				WithFields(log.Fields{"error": err.Error()}).
+				Warnf("Error on deleting the upload chunk %s", chunkKey)
+		}
+	}
+}
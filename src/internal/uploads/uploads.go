@@ -0,0 +1,145 @@
+package uploads
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/storage"
+	"recipes-v2-server/utils"
+	"strconv"
+	"time"
+)
+
+const presignedUploadExpiry = 15 * time.Minute
+
+// kinds maps the upload kinds the clients can ask for to the limits of the image
+var kinds = map[string]images.Kind{
+	"avatar": images.Avatar,
+	"cover":  images.CoverImage,
+	"recipe": images.RecipeImage,
+}
+
+// Presign issues a short-lived URL the user uploads an image to directly, without it going through the server. The
+// upload is only usable after it is confirmed by the same user.
+func Presign(userId int, request PresignRequest) (upload PresignedUpload, err error) {
+	kind := kinds[request.Kind]
+	if request.Size <= 0 || request.Size > kind.MaxBytes {
+		err = fileTooLargeError(kind)
+		return
+	}
+
+	randomPart := make([]byte, 16)
+	if _, err = rand.Read(randomPart); err != nil {
+		return
+	}
+
+	key := fmt.Sprintf("pending-uploads/%d-%s", userId, hex.EncodeToString(randomPart))
+
+	uploadURL, err := storage.PresignPut(key, request.ContentType, request.Size, presignedUploadExpiry)
+	if err != nil {
+		return
+	}
+
+	err = database.GetSingleRecordNamedQuery(
+		&upload,
+		`INSERT INTO pending_uploads (key, user_id, kind, content_type, size, created_at, expires_at)
+				VALUES (:key, :user_id, :kind, :content_type, :size, NOW(), NOW() + CAST(:expiry AS INTERVAL))
+				RETURNING key, expires_at;`,
+		map[string]interface{}{
+			"key":          key,
+			"user_id":      userId,
+			"kind":         request.Kind,
+			"content_type": request.ContentType,
+			"size":         request.Size,
+			"expiry":       presignedUploadExpiry.String(),
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	upload.UploadURL = uploadURL
+	upload.Method = "PUT"
+	upload.Headers = map[string]string{
+		"Content-Type":   request.ContentType,
+		"Content-Length": strconv.FormatInt(request.Size, 10),
+	}
+	return
+}
+
+// Confirm verifies that the pending upload was issued to the user for the given kind and is not expired, then hands
+// the uploaded file to attach, which validates and processes it and saves it where it belongs. The original upload
+// is removed afterwards, whether it was attached or not.
+func Confirm(userId int, key, kind string, attach func(content io.Reader) error) (err error) {
+	var upload pendingUpload
+
+	err = database.GetSingleRecordNamedQuery(
+		&upload,
+		`DELETE
+				FROM pending_uploads
+				WHERE key = :key
+				  AND user_id = :user_id
+				  AND kind = :kind
+				  AND expires_at > NOW()
+				RETURNING key, size;`,
+		map[string]interface{}{"key": key, "user_id": userId, "kind": kind},
+	)
+	if err != nil {
+		return
+	}
+	defer deleteUpload(upload.Key)
+
+	content, err := storage.Get(upload.Key)
+	if err != nil {
+		return
+	}
+	defer content.Close()
+
+	return attach(io.LimitReader(content, upload.Size))
+}
+
+// CleanUpExpired removes the uploads that were never confirmed and the resumable uploads that were never completed
+func CleanUpExpired() (err error) {
+	var keys []string
+
+	err = database.GetMultipleRecords(
+		&keys,
+		`WITH expired_tus_uploads AS (DELETE FROM tus_uploads WHERE expires_at < NOW() RETURNING chunk_keys),
+					 expired_uploads AS (DELETE FROM pending_uploads WHERE expires_at < NOW() RETURNING key)
+
+				SELECT key FROM expired_uploads
+				UNION ALL
+				SELECT UNNEST(chunk_keys) FROM expired_tus_uploads;`,
+	)
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		err = storage.Delete(key)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func fileTooLargeError(kind images.Kind) error {
+	return &images.ValidationError{
+		Code:    "file_too_large",
+		Message: fmt.Sprintf("the %s should be at most %d MB", kind.Name, kind.MaxBytes>>20),
+	}
+}
+
+func deleteUpload(key string) {
+	if err := storage.Delete(key); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Warnf("Error on deleting the confirmed upload %s", key)
+	}
+}
@@ -1,8 +1,14 @@
 package users
 
+import (
+	"github.com/lib/pq"
+	"recipes-v2-server/storage"
+	"time"
+)
+
 type BaseUserData struct {
-	Username  string `json:"username" db:"username"`
-	AvatarURL string `json:"avatarURL" db:"avatar_url"`
+	Username  string            `json:"username" db:"username"`
+	AvatarURL storage.ObjectKey `json:"avatarURL" db:"avatar_url"`
 }
 
 type OwnerData struct {
@@ -11,11 +17,12 @@ type OwnerData struct {
 }
 
 type User struct {
-	Username            string `json:"username" db:"username" valid:"required,minstringlength(3)"`
-	AvatarURL           string `json:"avatarURL" db:"avatar_url"`
-	CoverPhotoURL       string `json:"coverPhotoURL" db:"cover_photo_url"`
-	Email               string `json:"email" db:"email" valid:"required,email"`
-	CreatedRecipesCount int    `json:"createdRecipesCount" db:"created_recipes_count"`
+	Id                  int               `json:"-" db:"id"`
+	Username            string            `json:"username" db:"username" valid:"required,minstringlength(3)"`
+	AvatarURL           storage.ObjectKey `json:"avatarURL" db:"avatar_url"`
+	CoverPhotoURL       storage.ObjectKey `json:"coverPhotoURL" db:"cover_photo_url"`
+	Email               string            `json:"email" db:"email" valid:"required,email"`
+	CreatedRecipesCount int               `json:"createdRecipesCount" db:"created_recipes_count"`
 }
 
 type UserAdminData struct {
@@ -26,8 +33,9 @@ type UserAdminData struct {
 }
 
 type UserImages struct {
-	AvatarURL     string `json:"avatarURL" db:"avatar_url"`
-	CoverPhotoURL string `json:"coverPhotoURL" db:"cover_photo_url"`
+	AvatarURL        storage.ObjectKey `json:"avatarURL" db:"avatar_url"`
+	CoverPhotoURL    storage.ObjectKey `json:"coverPhotoURL" db:"cover_photo_url"`
+	CookLogPhotoURLs pq.StringArray    `json:"-" db:"cook_log_photo_urls"`
 }
 
 type UserChangeRoleData struct {
@@ -39,3 +47,14 @@ type BlockUserData struct {
 	UserId int    `db:"user_id" json:"userId" valid:"required"`
 	Reason string `db:"reason" json:"reason" valid:"required"`
 }
+
+type HideUserData struct {
+	Username string `db:"username" json:"username" valid:"required"`
+}
+
+type DeletedUser struct {
+	BaseUserData
+	Id        int       `db:"id" json:"id"`
+	Email     string    `db:"email" json:"email"`
+	DeletedAt time.Time `db:"deleted_at" json:"deletedAt"`
+}
@@ -0,0 +1,102 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/storage"
+)
+
+// GetDeleted gets the users in the trash, the most recently deleted first
+func GetDeleted() (users []DeletedUser, err error) {
+	err = database.GetMultipleRecords(
+		&users,
+		`SELECT id,
+					   email,
+					   username,
+					   COALESCE(avatar_url, '') AS avatar_url,
+					   deleted_at
+				FROM users
+				WHERE deleted_at IS NOT NULL
+				ORDER BY deleted_at DESC;`,
+	)
+	return
+}
+
+// Restore takes a user out of the trash, so the user can log in again
+func Restore(id int) (err error) {
+	var restoredId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&restoredId,
+		`UPDATE users SET deleted_at = NULL WHERE id = :id AND deleted_at IS NOT NULL RETURNING id;`,
+		map[string]interface{}{"id": id},
+	)
+	return
+}
+
+// PurgeDeleted permanently deletes the users that have been in the trash for longer than the given number of days.
+// A failing user does not stop the purge of the rest, the errors are joined. Returns the number of purged users.
+func PurgeDeleted(retentionDays int) (purged int, err error) {
+	var ids []int
+
+	err = database.GetMultipleRecordsNamedQuery(
+		&ids,
+		`SELECT id FROM users WHERE deleted_at < NOW() - MAKE_INTERVAL(days => :retention_days);`,
+		map[string]interface{}{"retention_days": retentionDays},
+	)
+	if err != nil {
+		return
+	}
+
+	for _, id := range ids {
+		if purgeErr := purge(id); purgeErr != nil {
+			err = errors.Join(err, fmt.Errorf("purging user %d: %w", id, purgeErr))
+			continue
+		}
+		purged++
+	}
+	return
+}
+
+// purge deletes a user, transfers their recipes to a preferred admin user and removes their images from storage
+func purge(id int) (err error) {
+	var oldImageURLs UserImages
+
+	err = database.GetSingleRecordNamedQuery(
+		&oldImageURLs,
+		`WITH transfer_recipes_to_admin AS (UPDATE recipes SET owner_id = 2 WHERE recipes.owner_id = :id),
+					 delete_favourites AS (DELETE FROM users_favourites WHERE user_entity_id = :id),
+					 delete_comments AS (DELETE FROM comments WHERE owner_id = :id),
+					 delete_cook_logs AS (DELETE FROM cook_logs WHERE user_id = :id RETURNING photo_url),
+					 delete_roles AS (DELETE FROM users_roles WHERE user_entity_id = :id),
+					 delete_ip_address AS (DELETE FROM user_entity_ip_addresses WHERE user_entity_id = :id)
+				
+				DELETE
+				FROM users
+				WHERE id = :id
+				RETURNING COALESCE(avatar_url, '') AS avatar_url, 
+						  COALESCE(cover_photo_url, '') AS cover_photo_url,
+						  ARRAY(SELECT photo_url FROM delete_cook_logs WHERE photo_url != '') AS cook_log_photo_urls;`,
+		map[string]interface{}{"id": id},
+	)
+	if err != nil {
+		return
+	}
+
+	var avatarErr, coverErr, cookLogPhotosErr error
+	if oldImageURLs.AvatarURL != "" {
+		avatarErr = images.Delete(oldImageURLs.AvatarURL)
+	}
+
+	if oldImageURLs.CoverPhotoURL != "" {
+		coverErr = images.Delete(oldImageURLs.CoverPhotoURL)
+	}
+
+	for _, photoURL := range oldImageURLs.CookLogPhotoURLs {
+		cookLogPhotosErr = errors.Join(cookLogPhotosErr, images.Delete(storage.ObjectKey(photoURL)))
+	}
+
+	return errors.Join(avatarErr, coverErr, cookLogPhotosErr)
+}
@@ -1,9 +1,12 @@
 package users
 
 import (
-	"bytes"
-	"mime/multipart"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"io"
 	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/storage"
 	"recipes-v2-server/utils"
 )
 
@@ -11,72 +14,89 @@ import (
 func GetUser(username string) (user User, err error) {
 	err = database.GetSingleRecordNamedQuery(
 		&user,
-		`SELECT email,
+		`SELECT users.id,
+					   email,
 					   username,
 					   COALESCE(avatar_url, '')      AS avatar_url,
 					   COALESCE(cover_photo_url, '') AS cover_photo_url,
 					   COUNT(recipes.id)             AS created_recipes_count
 				FROM users
-						 LEFT JOIN recipes ON recipes.owner_id = users.id
-				WHERE username = :username
-				GROUP BY avatar_url, cover_photo_url, email, username;`,
+						 LEFT JOIN recipes ON recipes.owner_id = users.id AND recipes.deleted_at IS NULL
+				WHERE username = :username AND users.deleted_at IS NULL
+				GROUP BY users.id, avatar_url, cover_photo_url, email, username;`,
 		map[string]interface{}{"username": username},
 	)
 	return
 }
 
-// UploadCoverImage uploads a new cover image for the user
-func UploadCoverImage(file *multipart.FileHeader, fileKey, username string) (imageURL string, err error) {
-	contentType := file.Header.Get("Content-Type")
-
-	fileContent, _ := file.Open()
-	buffer := make([]byte, file.Size)
-	_, _ = fileContent.Read(buffer)
-	fileBytes := bytes.NewReader(buffer)
-
-	err = utils.UploadToS3(fileBytes, fileKey, contentType)
+// UploadCoverImage uploads a new cover image for the user and removes the variants of the previous one
+func UploadCoverImage(content io.Reader, username string) (variants images.Variants, err error) {
+	variants, err = images.Upload(content, images.CoverImage)
 	if err != nil {
 		return
 	}
 
-	imageURL = utils.GetTheFullS3BucketURL() + "/" + fileKey
+	var oldImageKey storage.ObjectKey
 
-	_, err = database.ExecuteNamedQuery(
-		`UPDATE users SET cover_photo_url = :image_url WHERE username = :username;`,
-		map[string]interface{}{"username": username, "image_url": imageURL},
+	err = database.GetSingleRecordNamedQuery(
+		&oldImageKey,
+		`UPDATE users
+				SET cover_photo_url = :image_url
+				FROM (SELECT id, cover_photo_url FROM users WHERE username = :username) AS previous
+				WHERE users.id = previous.id
+				RETURNING COALESCE(previous.cover_photo_url, '');`,
+		map[string]interface{}{"username": username, "image_url": variants.Full},
 	)
+	if err != nil {
+		return
+	}
+
+	deleteReplacedImage(oldImageKey, variants)
 	return
 }
 
-// UploadAvatarImage uploads a new avatar image for the user
-func UploadAvatarImage(file *multipart.FileHeader, fileKey, username string) (imageURL string, err error) {
-	contentType := file.Header.Get("Content-Type")
-
-	fileContent, _ := file.Open()
-	buffer := make([]byte, file.Size)
-	_, _ = fileContent.Read(buffer)
-	fileBytes := bytes.NewReader(buffer)
-
-	err = utils.UploadToS3(fileBytes, fileKey, contentType)
+// UploadAvatarImage uploads a new avatar image for the user and removes the variants of the previous one
+func UploadAvatarImage(content io.Reader, username string) (variants images.Variants, err error) {
+	variants, err = images.Upload(content, images.Avatar)
 	if err != nil {
 		return
 	}
 
-	imageURL = utils.GetTheFullS3BucketURL() + "/" + fileKey
+	var oldImageKey storage.ObjectKey
 
-	_, err = database.ExecuteNamedQuery(
-		`UPDATE users SET avatar_url = :image_url WHERE username = :username;`,
-		map[string]interface{}{"username": username, "image_url": imageURL},
+	err = database.GetSingleRecordNamedQuery(
+		&oldImageKey,
+		`UPDATE users
+				SET avatar_url = :image_url
+				FROM (SELECT id, avatar_url FROM users WHERE username = :username) AS previous
+				WHERE users.id = previous.id
+				RETURNING COALESCE(previous.avatar_url, '');`,
+		map[string]interface{}{"username": username, "image_url": variants.Full},
 	)
+	if err != nil {
+		return
+	}
+
+	deleteReplacedImage(oldImageKey, variants)
 	return
 }
 
+// deleteReplacedImage only logs a failed clean up, since the new image is already saved
+func deleteReplacedImage(oldImageKey storage.ObjectKey, variants images.Variants) {
+	if err := images.DeleteReplaced(oldImageKey, variants); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Warnf("Error on deleting the replaced image %s", oldImageKey)
+	}
+}
+
 // EditData uploads a new avatar image for the user
 func EditData(oldUsername string, data User) (response User, err error) {
 	err = database.GetSingleRecordNamedQuery(
 		&response,
 		`UPDATE users SET username = :username, email = :email 
-             	WHERE username = :old_username 
+             	WHERE username = :old_username AND deleted_at IS NULL
              	RETURNING username, email, avatar_url, cover_photo_url;`,
 		map[string]interface{}{"username": data.Username, "email": data.Email, "old_username": oldUsername},
 	)
@@ -85,7 +105,7 @@ func EditData(oldUsername string, data User) (response User, err error) {
 
 // Count retrieves the total count of the users
 func Count() (count int, err error) {
-	err = database.GetSingleRecord(&count, `SELECT COUNT(id) FROM users;`)
+	err = database.GetSingleRecord(&count, `SELECT COUNT(id) FROM users WHERE deleted_at IS NULL;`)
 	return
 }
 
@@ -102,47 +122,27 @@ func GetAllUsers() (user []UserAdminData, err error) {
 					   COALESCE(cover_photo_url, '')    AS cover_photo_url,
 					   COUNT(recipes.id)                AS created_recipes_count
 				FROM users
-						 LEFT JOIN recipes ON recipes.owner_id = users.id
+						 LEFT JOIN recipes ON recipes.owner_id = users.id AND recipes.deleted_at IS NULL
 						 LEFT JOIN users_roles ON users.id = users_roles.user_entity_id
 						 LEFT JOIN roles ON users_roles.roles_id = roles.id
 						 LEFT JOIN user_entity_ip_addresses ON users.id = user_entity_ip_addresses.user_entity_id
 						 LEFT JOIN blacklist ON user_entity_ip_addresses.ip_addresses = blacklist.ip_address
+				WHERE users.deleted_at IS NULL
 				GROUP BY avatar_url, cover_photo_url, email, username, users.id, role, blacklist.ip_address;`,
 	)
 	return
 }
 
-// Delete deletes a user and transfers his recipes to a preferred admin user
+// Delete moves a user to the trash. The user can no longer log in and the comments of the user are hidden until the
+// user is restored or purged once the trash retention period passes.
 func Delete(id int) (err error) {
-	var oldImageURLs UserImages
+	var deletedId int
 
 	err = database.GetSingleRecordNamedQuery(
-		&oldImageURLs,
-		`WITH transfer_recipes_to_admin AS (UPDATE recipes SET owner_id = 2 WHERE recipes.owner_id = :id),
-					 delete_favourites AS (DELETE FROM users_favourites WHERE user_entity_id = :id),
-					 delete_comments AS (DELETE FROM comments WHERE owner_id = :id),
-					 delete_roles AS (DELETE FROM users_roles WHERE user_entity_id = :id),
-					 delete_ip_address AS (DELETE FROM user_entity_ip_addresses WHERE user_entity_id = :id)
-				
-				DELETE
-				FROM users
-				WHERE id = :id
-				RETURNING COALESCE(avatar_url, '') AS avatar_url, 
-						  COALESCE(cover_photo_url, '') AS cover_photo_url;`,
+		&deletedId,
+		`UPDATE users SET deleted_at = NOW() WHERE id = :id AND deleted_at IS NULL RETURNING id;`,
 		map[string]interface{}{"id": id},
 	)
-	if err != nil {
-		return
-	}
-
-	if oldImageURLs.AvatarURL != "" {
-		err = utils.DeleteFromS3(oldImageURLs.AvatarURL)
-	}
-
-	if oldImageURLs.CoverPhotoURL != "" {
-		err = utils.DeleteFromS3(oldImageURLs.CoverPhotoURL)
-	}
-
 	return
 }
 
@@ -179,3 +179,46 @@ func Unblock(userId int) (err error) {
 	)
 	return
 }
+
+// GetHidden gets the usernames of the users whose recipes are hidden from the feed of the given user
+func GetHidden(userId int) (usernames pq.StringArray, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&usernames,
+		`SELECT ARRAY(SELECT username
+					  FROM hidden_users
+							   JOIN users ON users.id = hidden_users.hidden_user_id
+					  WHERE user_id = :user_id
+					  ORDER BY username);`,
+		map[string]interface{}{"user_id": userId},
+	)
+	return
+}
+
+// Hide hides the recipes of the user with the given username from the feed of the given user
+func Hide(userId int, username string) (err error) {
+	var hiddenUserId int
+
+	err = database.GetSingleRecordNamedQuery(
+		&hiddenUserId,
+		`INSERT INTO hidden_users (user_id, hidden_user_id)
+				SELECT :user_id, id
+				FROM users
+				WHERE username = :username AND id != :user_id AND deleted_at IS NULL
+				ON CONFLICT (user_id, hidden_user_id) DO UPDATE SET hidden_user_id = EXCLUDED.hidden_user_id
+				RETURNING hidden_user_id;`,
+		map[string]interface{}{"user_id": userId, "username": username},
+	)
+	return
+}
+
+// Unhide shows the recipes of the user with the given username in the feed of the given user again
+func Unhide(userId int, username string) (err error) {
+	_, err = database.ExecuteNamedQuery(
+		`DELETE
+				FROM hidden_users
+				WHERE user_id = :user_id
+				  AND hidden_user_id = (SELECT id FROM users WHERE username = :username);`,
+		map[string]interface{}{"user_id": userId, "username": username},
+	)
+	return
+}
@@ -2,11 +2,17 @@ package main
 
 import (
 	log "github.com/sirupsen/logrus"
+	"os"
 	"recipes-v2-server/config"
 	"recipes-v2-server/database"
 	"recipes-v2-server/internal/auth"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/internal/trash"
 	"recipes-v2-server/server"
+	"recipes-v2-server/storage"
 	"recipes-v2-server/utils"
+	"strings"
 )
 
 func init() {
@@ -27,21 +33,61 @@ func init() {
 		app.DBName,
 	)
 
-	utils.CreateS3Session(
-		app.S3BucketName,
-		app.S3BucketKey,
-		app.S3BucketURL,
-		app.S3BucketRegion,
-		app.AWSAccessKey,
-		app.AWSSecretKey,
-		app.S3ACL,
-	)
+	if app.StorageBackend == "local" {
+		storage.UseLocal(app.LocalStorageDir, app.LocalStorageURL)
+	} else {
+		storage.UseS3(
+			app.S3BucketName,
+			app.S3BucketKey,
+			app.S3BucketURL,
+			app.S3BucketRegion,
+			app.AWSAccessKey,
+			app.AWSSecretKey,
+			app.S3ACL,
+		)
+	}
+	storage.SetPublicURL(app.PublicStorageURL)
+	images.GetOrphanGracePeriod(app.OrphanedImagesGracePeriodHours)
 
 	utils.GetJWTKey(app.JWTSecret)
 
 	auth.GetSaltRounds(app.Salt)
+
+	recipes.GetTrendingHalfLife(app.TrendingHalfLifeDays)
+
+	trash.GetRetentionPeriod(app.TrashRetentionDays)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate-image-keys" {
+		migrateImageKeys(os.Args[2:])
+		return
+	}
+
 	server.Run()
 }
+
+// migrateImageKeys rewrites the image URLs stored in the DB to object keys. Besides the URLs of the active storage,
+// older base URLs of the bucket or CDN can be passed as arguments.
+func migrateImageKeys(oldBaseURLs []string) {
+	baseURLs := storage.BaseURLs()
+	for _, baseURL := range oldBaseURLs {
+		baseURLs = append(baseURLs, strings.TrimSuffix(baseURL, "/")+"/")
+	}
+
+	migrated, err := images.MigrateURLsToKeys(baseURLs)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error on migrating the image URLs to keys")
+		os.Exit(1)
+	}
+	for column, rows := range migrated {
+		utils.GetLogger().Infof("Migrated %d image URLs to keys in %s", rows, column)
+	}
+
+	migratedRecipes, err := recipes.MigrateStepImageURLsToKeys(baseURLs)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error on migrating the step image URLs to keys")
+		os.Exit(1)
+	}
+	utils.GetLogger().Infof("Migrated the step image URLs to keys in %d recipes", migratedRecipes)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"recipes-v2-server/utils"
+)
+
+// getRequestClaims parses the X-Authorization header of the request and returns the claims of the authenticated user
+func getRequestClaims(ginCtx *gin.Context) (claims *utils.TokenClaims, err error) {
+	if len(ginCtx.Request.Header["X-Authorization"]) == 0 || len(ginCtx.Request.Header["X-Authorization"][0]) == 0 {
+		return nil, errors.New("missing auth token")
+	}
+
+	claims, isValid, err := utils.ParseJWT(ginCtx.Request.Header["X-Authorization"][0])
+	if err != nil {
+		return
+	}
+	if !isValid {
+		return nil, errors.New("invalid token")
+	}
+	return
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"fmt"
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/collections"
+	"recipes-v2-server/utils"
+	"strconv"
+)
+
+func GetUserCollections(ginCtx *gin.Context) {
+	username, ok := ginCtx.Params.Get("username")
+
+	if !ok {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "username was not found"})
+		return
+	}
+
+	var requesterId int
+	if claims, err := getRequestClaims(ginCtx); err == nil {
+		requesterId = claims.Id
+	}
+
+	collectionsData, err := collections.GetForUser(username, requesterId)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the collections of user %s", username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, collectionsData)
+}
+
+func GetCollection(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	var requesterId int
+	if claims, claimsErr := getRequestClaims(ginCtx); claimsErr == nil {
+		requesterId = claims.Id
+	}
+
+	collection, err := collections.GetById(collectionId, requesterId)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusNotFound, map[string]interface{}{"error": "no such collection"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting collection %d", collectionId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, collection)
+}
+
+func CreateCollection(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request := collections.CollectionRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request.OwnerId = claims.Id
+
+	collection, err := collections.Create(request)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on create attempt for collection %s", request.Name)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, collection)
+}
+
+func EditCollection(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	request := collections.CollectionRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request.Id = collectionId
+
+	collection, err := collections.Edit(request)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such collection"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on edit attempt for collection %d", collectionId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, collection)
+}
+
+func DeleteCollection(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = collections.Delete(collectionId)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such collection"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on delete attempt for collection %d", collectionId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+func AddRecipeToCollection(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request := collections.CollectionRecipeRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	err = collections.AddRecipe(collectionId, claims.Id, request.RecipeName)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on adding recipe %s to collection %d", request.RecipeName, collectionId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func RemoveRecipeFromCollection(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	request := collections.CollectionRecipeRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	err = collections.RemoveRecipe(collectionId, request.RecipeName)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on removing recipe %s from collection %d", request.RecipeName, collectionId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func ReorderCollectionRecipes(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	request := collections.CollectionOrderRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil || len(request.RecipeNames) == 0 {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters, expected a non empty recipeNames list"})
+		return
+	}
+
+	err = collections.Reorder(collectionId, request.RecipeNames)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on reordering the recipes of collection %d", collectionId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func AddCollectionCollaborator(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	request := collections.CollaboratorRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	err = collections.AddCollaborator(collectionId, request.Username)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such user found"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on adding collaborator %s to collection %d", request.Username, collectionId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func RemoveCollectionCollaborator(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	username := ginCtx.Param("username")
+
+	err = collections.RemoveCollaborator(collectionId, username)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on removing collaborator %s from collection %d", username, collectionId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func UploadCollectionCoverImage(ginCtx *gin.Context) {
+	collectionId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	imageKey := fmt.Sprintf("collection-%d-cover-image", collectionId)
+
+	coverImage, err := ginCtx.FormFile(imageKey)
+	if err != nil {
+		ginCtx.JSON(
+			http.StatusBadRequest,
+			map[string]interface{}{"error": fmt.Sprintf("the expected key - %s was not found in the form data", imageKey)},
+		)
+		return
+	}
+
+	coverImageContent, err := coverImage.Open()
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the uploaded file could not be read"})
+		return
+	}
+	defer coverImageContent.Close()
+
+	variants, err := collections.UploadCoverImage(coverImageContent, collectionId)
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on attempting to upload collection cover image")
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"coverImageURL": variants.Full, "variants": variants})
+}
@@ -209,3 +209,49 @@ func DeleteAdminComment(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
+
+func GetDeletedComments(ctx *gin.Context) {
+	deleted, err := comments.GetDeleted()
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on getting the deleted comments")
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, deleted)
+}
+
+func RestoreComment(ctx *gin.Context) {
+	commentId, ok := ctx.Params.Get("id")
+
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "comment id was not found"})
+		return
+	}
+
+	commentIdAsNumber, err := strconv.Atoi(commentId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = comments.Restore(commentIdAsNumber)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such deleted comment"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on restore attempt for comment %s", commentId)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/olahol/melody"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"recipes-v2-server/internal/cooklogs"
+	"recipes-v2-server/internal/notifications"
+	"recipes-v2-server/utils"
+	"strconv"
+)
+
+const cookLogPhotoKey = "cook-log-photo"
+
+func GetRecipeCookLogs(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	cookLogs, err := cooklogs.GetForRecipe(recipeName)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the cook logs of recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, cookLogs)
+}
+
+func GetRecipeCookLogPhotos(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	cookLogs, err := cooklogs.GetPhotos(recipeName)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the cook log photos of recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, cookLogs)
+}
+
+// CreateCookLog records that the user cooked the recipe and notifies the recipe owner through the websocket
+func CreateCookLog(websocket *melody.Melody) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		claims, err := getRequestClaims(ginCtx)
+		if err != nil {
+			ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+			return
+		}
+
+		request := cooklogs.CookLogRequest{}
+
+		if err = ginCtx.ShouldBind(&request); err != nil {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+			return
+		}
+
+		if _, err = validator.ValidateStruct(request); err != nil {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+			return
+		}
+
+		if _, err = cooklogs.ParseCookedOn(request.CookedOn); err != nil {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+			return
+		}
+
+		request.RecipeName = ginCtx.Param("name")
+		request.UserId = claims.Id
+
+		var photo io.Reader
+		photoFile, err := ginCtx.FormFile(cookLogPhotoKey)
+		if err != nil && !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart) {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the uploaded file could not be read"})
+			return
+		}
+		if photoFile != nil {
+			photoContent, err := photoFile.Open()
+			if err != nil {
+				ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the uploaded file could not be read"})
+				return
+			}
+			defer photoContent.Close()
+			photo = photoContent
+		}
+
+		cookLog, err := cooklogs.Create(request, photo)
+		if respondToImageValidationError(ginCtx, err) {
+			return
+		}
+		if err != nil {
+			if err.Error() == "sql: no rows in result set" {
+				ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+				return
+			}
+
+			utils.
+				GetLogger().
+				WithFields(log.Fields{"error": err.Error()}).
+				Errorf("Error on adding a cook log of recipe %s for user %s", request.RecipeName, claims.Username)
+
+			ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+			return
+		}
+
+		notifyAboutCookLog(websocket, cookLog)
+		ginCtx.JSON(http.StatusCreated, cookLog)
+	}
+}
+
+func DeleteCookLog(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	cookLogId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = cooklogs.Delete(claims.Id, cookLogId)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such cook log"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on delete attempt for cook log %d", cookLogId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// notifyAboutCookLog only logs a failed notification, since the cook log is already saved
+func notifyAboutCookLog(websocket *melody.Melody, cookLog cooklogs.CookLog) {
+	receivers, err := notifications.Create(notifications.NotificationRequest{
+		SenderAvatar:   cookLog.UserAvatar.URL(),
+		SenderUsername: cookLog.Username,
+		SenderId:       cookLog.UserId,
+		Action:         "COOKED_RECIPE",
+		LocationName:   cookLog.RecipeName,
+	})
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on notifying about the cook log of recipe %s", cookLog.RecipeName)
+		return
+	}
+
+	receiversUsernames, _ := json.Marshal(receivers)
+	if err = websocket.Broadcast(receiversUsernames); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"warning": err.Error(), "receivers": receiversUsernames}).
+			Warn("Error on send receiver ids attempt")
+	}
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"recipes-v2-server/internal/images"
+)
+
+// respondToImageValidationError answers with the reason the uploaded image was rejected. Returns false when the error
+// is not a validation error, so the caller handles it.
+func respondToImageValidationError(ginCtx *gin.Context, err error) bool {
+	var validationError *images.ValidationError
+	if !errors.As(err, &validationError) {
+		return false
+	}
+
+	ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": validationError})
+	return true
+}
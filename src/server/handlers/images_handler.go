@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/utils"
+)
+
+// GetOrphanedImages is a dry run of the orphaned images job - it reports what the job would delete
+func GetOrphanedImages(ginCtx *gin.Context) {
+	report, err := images.FindOrphans()
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on finding the orphaned images")
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, report)
+}
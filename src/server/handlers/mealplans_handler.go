@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/mealplans"
+	"recipes-v2-server/utils"
+	"strconv"
+	"time"
+)
+
+func GetMealPlan(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	from, to, ok := parseMealPlanPeriod(ginCtx)
+	if !ok {
+		return
+	}
+
+	entries, err := mealplans.GetForPeriod(claims.Id, from, to)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the meal plan for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, entries)
+}
+
+func AddMealPlanEntry(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request := mealplans.MealPlanEntryRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if _, err = mealplans.ParseDate(request.Date); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "date should be in the YYYY-MM-DD format"})
+		return
+	}
+
+	request.UserId = claims.Id
+
+	entry, err := mealplans.AddEntry(request)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on adding a meal plan entry for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, entry)
+}
+
+func DeleteMealPlanEntry(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	entryId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = mealplans.DeleteEntry(claims.Id, entryId)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such meal plan entry"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on delete attempt for meal plan entry %d", entryId)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+func CopyMealPlanWeek(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request := mealplans.CopyWeekRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	fromWeekStart, fromErr := mealplans.ParseDate(request.FromWeekStart)
+	toWeekStart, toErr := mealplans.ParseDate(request.ToWeekStart)
+	if fromErr != nil || toErr != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "dates should be in the YYYY-MM-DD format"})
+		return
+	}
+
+	copied, err := mealplans.CopyWeek(claims.Id, fromWeekStart, toWeekStart)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on copying the meal plan week for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"copied": copied})
+}
+
+func GetMealPlanNutrition(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	from, to, ok := parseMealPlanPeriod(ginCtx)
+	if !ok {
+		return
+	}
+
+	nutrition, err := mealplans.GetDailyNutrition(claims.Id, from, to)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the meal plan nutrition for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, nutrition)
+}
+
+// GetMealPlanCalendar is requested by calendar apps which can not send custom headers, so the calendar feed token is
+// expected as a query parameter instead. The feed token is issued only for this endpoint and can be revoked.
+func GetMealPlanCalendar(ginCtx *gin.Context) {
+	token := ginCtx.Query("token")
+	if token == "" {
+		ginCtx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := mealplans.GetCalendarTokenOwner(token)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on checking a meal plan calendar token")
+
+		ginCtx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+
+	calendar, err := mealplans.Calendar(userId, now.AddDate(0, 0, -30), now.AddDate(0, 0, 90))
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on generating the meal plan calendar for user %d", userId)
+
+		ginCtx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	ginCtx.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+// CreateMealPlanCalendarToken issues the token calendar apps subscribe to the meal plan with. Issuing a new token
+// revokes the previous one.
+func CreateMealPlanCalendarToken(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	token, err := mealplans.CreateCalendarToken(claims.Id)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on creating a meal plan calendar token for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"token": token})
+}
+
+func RevokeMealPlanCalendarToken(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if err = mealplans.RevokeCalendarToken(claims.Id); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on revoking the meal plan calendar token of user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+func parseMealPlanPeriod(ginCtx *gin.Context) (from, to time.Time, ok bool) {
+	from, fromErr := mealplans.ParseDate(ginCtx.Query("from"))
+	to, toErr := mealplans.ParseDate(ginCtx.Query("to"))
+	if fromErr != nil || toErr != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "from and to are required parameters in the YYYY-MM-DD format"})
+		return
+	}
+
+	if to.Before(from) {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "to should not be before from"})
+		return
+	}
+	return from, to, true
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/prices"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/utils"
+	"strconv"
+)
+
+func GetIngredientPrices(ctx *gin.Context) {
+	ingredientPrices, err := prices.GetAll()
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on getting the ingredient prices")
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, ingredientPrices)
+}
+
+func SaveIngredientPrice(ctx *gin.Context) {
+	request := prices.IngredientPriceRequest{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err := validator.ValidateStruct(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if request.PricePerUnit <= 0 {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "pricePerUnit should be positive"})
+		return
+	}
+
+	price, err := prices.Save(request)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on saving the price of %s", request.Ingredient)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+
+	go refreshRecipeCostEstimates()
+	ctx.JSON(http.StatusOK, price)
+}
+
+func DeleteIngredientPrice(ctx *gin.Context) {
+	priceId, ok := ctx.Params.Get("id")
+
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "price id was not found"})
+		return
+	}
+
+	priceIdAsNumber, err := strconv.Atoi(priceId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = prices.Delete(priceIdAsNumber)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such price"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on delete attempt for price %s", priceId)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+
+	go refreshRecipeCostEstimates()
+	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+// refreshRecipeCostEstimates recalculates the recipe costs after a price change without holding up the response
+func refreshRecipeCostEstimates() {
+	if err := recipes.RefreshCostEstimates(); err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on refreshing the recipe cost estimates")
+	}
+}
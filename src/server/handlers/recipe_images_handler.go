@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/utils"
+	"strconv"
+)
+
+const (
+	recipeImageKey = "recipe-image"
+	stepImageKey   = "step-image"
+)
+
+func GetRecipeImages(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	recipeImages, err := recipes.GetImages(recipeName)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the images of recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, recipeImages)
+}
+
+func AddRecipeImage(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	request := recipes.RecipeImageEditRequest{}
+
+	if err := ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err := validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	recipeImage, err := ginCtx.FormFile(recipeImageKey)
+	if err != nil {
+		ginCtx.JSON(
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "the expected key - " + recipeImageKey + " was not found in the form data"},
+		)
+		return
+	}
+
+	recipeImageContent, err := recipeImage.Open()
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the uploaded file could not be read"})
+		return
+	}
+	defer recipeImageContent.Close()
+
+	result, err := recipes.AddImage(recipeName, recipeImageContent, request.AltText)
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on adding an image to recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, result)
+}
+
+func EditRecipeImage(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	imageId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	request := recipes.RecipeImageEditRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	err = recipes.EditImage(recipeName, imageId, request)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe image"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on editing image %d of recipe %s", imageId, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func ReorderRecipeImages(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	request := recipes.RecipeImagesOrderRequest{}
+
+	if err := ginCtx.ShouldBind(&request); err != nil || len(request.ImageIds) == 0 {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters, expected a non empty imageIds list"})
+		return
+	}
+
+	err := recipes.ReorderImages(recipeName, request.ImageIds)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on reordering the images of recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func DeleteRecipeImage(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	imageId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = recipes.DeleteImage(recipeName, imageId)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(
+				http.StatusBadRequest,
+				map[string]interface{}{"error": "no such recipe image or it is the only image of the recipe"},
+			)
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on deleting image %d of recipe %s", imageId, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func UploadRecipeStepImage(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	stepNumber, err := strconv.Atoi(ginCtx.Param("step"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "step should be of type int"})
+		return
+	}
+
+	stepImage, err := ginCtx.FormFile(stepImageKey)
+	if err != nil {
+		ginCtx.JSON(
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "the expected key - " + stepImageKey + " was not found in the form data"},
+		)
+		return
+	}
+
+	stepImageContent, err := stepImage.Open()
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the uploaded file could not be read"})
+		return
+	}
+	defer stepImageContent.Close()
+
+	variants, err := recipes.SetStepImage(recipeName, stepNumber, stepImageContent)
+	if err != nil {
+		handleStepImageError(ginCtx, err, recipeName, stepNumber)
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"imageURL": variants.Full, "variants": variants})
+}
+
+func DeleteRecipeStepImage(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	stepNumber, err := strconv.Atoi(ginCtx.Param("step"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "step should be of type int"})
+		return
+	}
+
+	err = recipes.DeleteStepImage(recipeName, stepNumber)
+	if err != nil {
+		handleStepImageError(ginCtx, err, recipeName, stepNumber)
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"success": true})
+}
+
+func handleStepImageError(ginCtx *gin.Context, err error, recipeName string, stepNumber int) {
+	if err.Error() == "sql: no rows in result set" {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+		return
+	}
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
+	var stepOutOfRangeError *recipes.StepOutOfRangeError
+
+	if errors.As(err, &stepOutOfRangeError) {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": stepOutOfRangeError.Error()})
+		return
+	}
+
+	utils.
+		GetLogger().
+		WithFields(log.Fields{"error": err.Error()}).
+		Errorf("Error on changing the image of step %d of recipe %s", stepNumber, recipeName)
+
+	ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/utils"
+	"strconv"
+)
+
+func GetRecipeNotes(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	recipeName := ginCtx.Param("name")
+
+	notes, err := recipes.GetNotes(recipeName, claims.Id)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the notes of user %s on recipe %s", claims.Username, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, notes)
+}
+
+func CreateRecipeNote(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	recipeName := ginCtx.Param("name")
+
+	request := recipes.RecipeNoteRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	note, err := recipes.CreateNote(recipeName, claims.Id, request)
+	if err != nil {
+		var invalidNoteError *recipes.InvalidNoteError
+
+		if errors.As(err, &invalidNoteError) {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": invalidNoteError.Error()})
+			return
+		}
+
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on adding a note of user %s to recipe %s", claims.Username, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, note)
+}
+
+func EditRecipeNote(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	recipeName := ginCtx.Param("name")
+
+	noteId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	request := recipes.RecipeNoteRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	note, err := recipes.EditNote(recipeName, claims.Id, noteId, request.Content)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such note"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on editing note %d of recipe %s", noteId, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, note)
+}
+
+func DeleteRecipeNote(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	recipeName := ginCtx.Param("name")
+
+	noteId, err := strconv.Atoi(ginCtx.Param("id"))
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = recipes.DeleteNote(recipeName, claims.Id, noteId)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such note"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on delete attempt for note %d of recipe %s", noteId, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
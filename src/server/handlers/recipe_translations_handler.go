@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/utils"
+)
+
+// requestedLanguage picks the language of the recipe texts from the lang query parameter or the Accept-Language header
+func requestedLanguage(ginCtx *gin.Context) string {
+	return recipes.MatchLanguage(ginCtx.Query("lang"), ginCtx.GetHeader("Accept-Language"))
+}
+
+func GetRecipeTranslations(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	translations, err := recipes.GetTranslations(recipeName)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the translations of recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, translations)
+}
+
+func SaveRecipeTranslation(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	language := ginCtx.Param("lang")
+	if !recipes.IsSupportedLanguage(language) {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the language should be bg or en"})
+		return
+	}
+
+	translation := recipes.RecipeTranslation{}
+
+	if err := ginCtx.ShouldBind(&translation); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err := validator.ValidateStruct(translation); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+	translation.Language = language
+
+	result, err := recipes.SaveTranslation(recipeName, translation)
+	if err != nil {
+		var invalidTranslationError *recipes.InvalidTranslationError
+
+		if errors.As(err, &invalidTranslationError) {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": invalidTranslationError.Error()})
+			return
+		}
+
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on saving the %s translation of recipe %s", language, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, result)
+}
+
+func DeleteRecipeTranslation(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+	language := ginCtx.Param("lang")
+
+	err := recipes.DeleteTranslation(recipeName, language)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such translation"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on deleting the %s translation of recipe %s", language, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	validator "github.com/asaskevich/govalidator"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/internal/users"
 	"recipes-v2-server/utils"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func GetAllRecipes(ginCtx *gin.Context) {
@@ -23,8 +27,13 @@ func GetAllRecipes(ginCtx *gin.Context) {
 		return
 	}
 
+	costFilter, ok := getCostFilter(ginCtx)
+	if !ok {
+		return
+	}
+
 	if search != "" {
-		recipesData, err := recipes.Search(search)
+		recipesData, err := recipes.Search(search, requestedLanguage(ginCtx), costFilter)
 		if err != nil {
 			if err.Error() == "sql: no rows in result set" {
 				ginCtx.JSON(http.StatusOK, map[string]interface{}{})
@@ -43,7 +52,7 @@ func GetAllRecipes(ginCtx *gin.Context) {
 		return
 	}
 
-	recipesData, err := recipes.GetAll(limit, cursor)
+	recipesData, err := recipes.GetAll(limit, cursor, requestedLanguage(ginCtx), costFilter)
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			ginCtx.JSON(http.StatusOK, map[string]interface{}{})
@@ -61,8 +70,30 @@ func GetAllRecipes(ginCtx *gin.Context) {
 	ginCtx.JSON(http.StatusOK, recipesData)
 }
 
+// getCostFilter reads the optional maxCost filter of the recipe listings together with the currency it is in and
+// answers with a bad request when the cost is not a positive number or the currency is not a three letter code
+func getCostFilter(ginCtx *gin.Context) (costFilter recipes.CostFilter, ok bool) {
+	maxCostAsString := ginCtx.Query("maxCost")
+	if maxCostAsString == "" {
+		return costFilter, true
+	}
+
+	maxCost, err := strconv.ParseFloat(maxCostAsString, 64)
+	if err != nil || maxCost <= 0 {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "maxCost should be a positive number"})
+		return costFilter, false
+	}
+
+	currency := strings.ToUpper(ginCtx.Query("currency"))
+	if len(currency) != 3 || !validator.IsAlpha(currency) {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "currency should be a three letter code when maxCost is provided"})
+		return costFilter, false
+	}
+	return recipes.CostFilter{MaxCost: maxCost, Currency: currency}, true
+}
+
 func GetLatestRecipes(ginCtx *gin.Context) {
-	latestRecipes, err := recipes.GetLatest()
+	latestRecipes, err := recipes.GetLatest(requestedLanguage(ginCtx))
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			ginCtx.JSON(http.StatusOK, map[string]interface{}{})
@@ -81,7 +112,7 @@ func GetLatestRecipes(ginCtx *gin.Context) {
 }
 
 func GetMostPopularRecipes(ginCtx *gin.Context) {
-	mostPopularRecipes, err := recipes.GetMostPopular()
+	mostPopularRecipes, err := recipes.GetMostPopular(requestedLanguage(ginCtx))
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			ginCtx.JSON(http.StatusOK, map[string]interface{}{})
@@ -99,6 +130,59 @@ func GetMostPopularRecipes(ginCtx *gin.Context) {
 	ginCtx.JSON(http.StatusOK, mostPopularRecipes)
 }
 
+func GetRecipesFeed(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	limit := 12
+	if limitAsString := ginCtx.Query("limit"); limitAsString != "" {
+		limit, err = strconv.Atoi(limitAsString)
+		if err != nil || limit < 1 || limit > 50 {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "limit should be a number between 1 and 50"})
+			return
+		}
+	}
+
+	feed, err := recipes.GetFeed(claims.Id, limit)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the recipes feed for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, feed)
+}
+
+func GetTrendingRecipes(ginCtx *gin.Context) {
+	limit := 10
+	if limitAsString := ginCtx.Query("limit"); limitAsString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitAsString)
+		if err != nil || limit < 1 || limit > 50 {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "limit should be a number between 1 and 50"})
+			return
+		}
+	}
+
+	trendingRecipes, err := recipes.GetTrending(limit)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on getting the trending recipes from the database")
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, trendingRecipes)
+}
+
 func GetByCategory(ginCtx *gin.Context) {
 	query := ginCtx.Request.URL.Query().Get("name")
 
@@ -107,7 +191,12 @@ func GetByCategory(ginCtx *gin.Context) {
 		return
 	}
 
-	recipesData, err := recipes.SearchByCategory(query)
+	costFilter, ok := getCostFilter(ginCtx)
+	if !ok {
+		return
+	}
+
+	recipesData, err := recipes.SearchByCategory(query, requestedLanguage(ginCtx), costFilter)
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			ginCtx.JSON(http.StatusOK, map[string]interface{}{})
@@ -133,7 +222,13 @@ func GetRecipe(ginCtx *gin.Context) {
 		return
 	}
 
-	recipe, err := recipes.GetASingleRecipe(recipeName)
+	// the notes are private, so they are only returned to their authenticated owner
+	var viewerId int
+	if claims, err := getRequestClaims(ginCtx); err == nil {
+		viewerId = claims.Id
+	}
+
+	recipe, err := recipes.GetASingleRecipe(recipeName, requestedLanguage(ginCtx), viewerId)
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
 			ginCtx.JSON(http.StatusOK, map[string]interface{}{})
@@ -151,6 +246,69 @@ func GetRecipe(ginCtx *gin.Context) {
 	ginCtx.JSON(http.StatusOK, recipe)
 }
 
+func GetSimilarRecipes(ginCtx *gin.Context) {
+	recipeName, ok := ginCtx.Params.Get("name")
+
+	if !ok {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "recipe name was not found"})
+		return
+	}
+
+	similarRecipes, err := recipes.GetSimilar(recipeName)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting similar recipes for recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, similarRecipes)
+}
+
+func GetRecipeCookMode(ginCtx *gin.Context) {
+	recipeName, ok := ginCtx.Params.Get("name")
+
+	if !ok {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "recipe name was not found"})
+		return
+	}
+
+	stepNumber := 1
+	if stepAsString := ginCtx.Query("step"); stepAsString != "" {
+		var err error
+		stepNumber, err = strconv.Atoi(stepAsString)
+		if err != nil {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "step should be of type int"})
+			return
+		}
+	}
+
+	cookStep, err := recipes.GetCookModeStep(recipeName, stepNumber, requestedLanguage(ginCtx))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusNotFound, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+		var stepOutOfRangeError *recipes.StepOutOfRangeError
+
+		if errors.As(err, &stepOutOfRangeError) {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": stepOutOfRangeError.Error()})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting cook mode step %d for recipe %s", stepNumber, recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, cookStep)
+}
+
 func GetRecipesByUser(ginCtx *gin.Context) {
 	username, ok := ginCtx.Params.Get("username")
 
@@ -285,6 +443,12 @@ func RemoveFromFavourites(ginCtx *gin.Context) {
 }
 
 func CreateRecipe(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
 	recipe := recipes.RecipeData{}
 	fmt.Println(recipe)
 
@@ -299,6 +463,34 @@ func CreateRecipe(ginCtx *gin.Context) {
 		return
 	}
 
+	// the servings can be left out only when editing the recipes created before they were recorded
+	if recipe.Servings == 0 {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "servings: non zero value required"})
+		return
+	}
+
+	if err := recipes.ValidateSteps(recipe); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if err := recipes.ValidateImage("", recipe.ImageURL, claims.Id); err != nil {
+		var invalidImageError *recipes.InvalidImageError
+
+		if errors.As(err, &invalidImageError) {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": invalidImageError.Error()})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on validating the image of a new recipe")
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+
 	authToken := ginCtx.Request.Header["X-Authorization"][0]
 
 	recipeData, err := recipes.Create(recipe, authToken)
@@ -342,6 +534,12 @@ func CheckRecipeName(ginCtx *gin.Context) {
 }
 
 func UploadRecipeImage(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
 	recipeName, found := ginCtx.GetPostForm("recipeName")
 	if !found {
 		ginCtx.JSON(
@@ -362,7 +560,17 @@ func UploadRecipeImage(ginCtx *gin.Context) {
 		return
 	}
 
-	imageURL, err := recipes.UploadRecipeImage(recipeImage, imageKey)
+	recipeImageContent, err := recipeImage.Open()
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the uploaded file could not be read"})
+		return
+	}
+	defer recipeImageContent.Close()
+
+	variants, err := recipes.UploadRecipeImage(recipeImageContent, claims.Id)
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
 	if err != nil {
 		utils.
 			GetLogger().
@@ -372,7 +580,7 @@ func UploadRecipeImage(ginCtx *gin.Context) {
 		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
 		return
 	}
-	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"imageURL": imageURL})
+	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"imageURL": variants.Full, "variants": variants})
 }
 
 func EditRecipe(ginCtx *gin.Context) {
@@ -383,6 +591,12 @@ func EditRecipe(ginCtx *gin.Context) {
 		return
 	}
 
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
 	data := recipes.RecipeData{}
 
 	if err := ginCtx.ShouldBind(&data); err != nil {
@@ -395,6 +609,28 @@ func EditRecipe(ginCtx *gin.Context) {
 		return
 	}
 
+	if err := recipes.ValidateSteps(data); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if err := recipes.ValidateImage(recipeName, data.ImageURL, claims.Id); err != nil {
+		var invalidImageError *recipes.InvalidImageError
+
+		if errors.As(err, &invalidImageError) {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": invalidImageError.Error()})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on validating the image of recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+
 	recipeData, err := recipes.Edit(recipeName, data)
 	if err != nil {
 		if err.Error() == "sql: no rows in result set" {
@@ -439,6 +675,119 @@ func DeleteRecipe(ginCtx *gin.Context) {
 	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
 
+func ForkRecipe(ginCtx *gin.Context) {
+	recipeName, ok := ginCtx.Params.Get("name")
+
+	if !ok {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "recipe name was not found"})
+		return
+	}
+
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request := recipes.ForkRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if request.RecipeName == "" {
+		request.RecipeName = fmt.Sprintf("%s (adapted by %s)", recipeName, claims.Username)
+	}
+
+	nameIsTaken, err := recipes.RecipeNameExists(request.RecipeName)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on checking for recipe name")
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+
+	if nameIsTaken {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "recipe name is already taken"})
+		return
+	}
+
+	recipeData, err := recipes.Fork(recipeName, request.RecipeName, users.OwnerData{Id: claims.Id, Username: claims.Username})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on fork attempt for recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, recipeData)
+}
+
+func GetRecipeForks(ginCtx *gin.Context) {
+	recipeName, ok := ginCtx.Params.Get("name")
+
+	if !ok {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "recipe name was not found"})
+		return
+	}
+
+	forks, err := recipes.GetForks(recipeName)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the forks of recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, forks)
+}
+
+func PublishRecipe(ginCtx *gin.Context) {
+	recipeName, ok := ginCtx.Params.Get("name")
+
+	if !ok {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "recipe name was not found"})
+		return
+	}
+
+	authToken := ginCtx.Request.Header["X-Authorization"][0]
+
+	err := recipes.Publish(recipeName, authToken)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such draft recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on publish attempt for recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
 func GetRecipesCount(ginCtx *gin.Context) {
 	recipesCount, err := recipes.Count()
 	if err != nil {
@@ -504,6 +853,84 @@ func DeleteAdminRecipe(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
 
+func GetDeletedRecipes(ctx *gin.Context) {
+	deleted, err := recipes.GetDeleted()
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on getting the deleted recipes")
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, deleted)
+}
+
+func RestoreRecipe(ctx *gin.Context) {
+	recipeId, ok := ctx.Params.Get("id")
+
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "recipe id was not found"})
+		return
+	}
+
+	recipeIdAsNumber, err := strconv.Atoi(recipeId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = recipes.Restore(recipeIdAsNumber)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such deleted recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on restore attempt for recipe %s", recipeId)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+func GetRecipeDuplicates(ctx *gin.Context) {
+	recipeId, ok := ctx.Params.Get("id")
+
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "recipe id was not found"})
+		return
+	}
+
+	recipeIdAsNumber, err := strconv.Atoi(recipeId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	duplicates, err := recipes.GetDuplicatesForModeration(recipeIdAsNumber)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on duplicate check for recipe %s", recipeId)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, duplicates)
+}
+
 func ApproveRecipe(ctx *gin.Context) {
 	recipeId, ok := ctx.Params.Get("id")
 
@@ -535,3 +962,67 @@ func ApproveRecipe(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
+
+func ScheduleRecipe(ctx *gin.Context) {
+	recipeId, ok := ctx.Params.Get("id")
+
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "recipe id was not found"})
+		return
+	}
+
+	recipeIdAsNumber, err := strconv.Atoi(recipeId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	request := recipes.RecipeScheduleRequest{}
+
+	if err = ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	publishAt, _ := time.Parse(time.RFC3339, request.PublishAt)
+	if !publishAt.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "publishAt should be in the future"})
+		return
+	}
+
+	scheduled, err := recipes.Schedule(recipeIdAsNumber, publishAt)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such pending or scheduled recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on schedule attempt for recipe %s", recipeId)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+func GetScheduledRecipes(ctx *gin.Context) {
+	scheduled, err := recipes.GetScheduled()
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on getting the scheduled recipes")
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduled)
+}
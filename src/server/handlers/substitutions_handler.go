@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/internal/substitutions"
+	"recipes-v2-server/utils"
+	"strconv"
+	"strings"
+)
+
+func GetRecipeSubstitutions(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	// the diets can be repeated query parameters or a comma separated list - ?diet=vegan&diet=gluten-free
+	var diets []string
+	for _, diet := range ginCtx.QueryArray("diet") {
+		for _, part := range strings.Split(diet, ",") {
+			if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+				diets = append(diets, part)
+			}
+		}
+	}
+	if unknown := substitutions.UnknownDiets(diets); len(unknown) > 0 {
+		ginCtx.JSON(
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "unsupported diet " + unknown[0] + ", expected one of " + strings.Join(substitutions.Diets, ", ")},
+		)
+		return
+	}
+
+	suggestions, err := recipes.GetSubstitutions(recipeName, diets)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such recipe"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting the substitutions for recipe %s", recipeName)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, suggestions)
+}
+
+func GetIngredientSubstitutions(ctx *gin.Context) {
+	knowledgeBase, err := substitutions.GetAll()
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on getting the ingredient substitutions")
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, knowledgeBase)
+}
+
+func SaveIngredientSubstitution(ctx *gin.Context) {
+	request := substitutions.SubstitutionRequest{}
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err := validator.ValidateStruct(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if request.Ratio < 0 {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "ratio should be positive"})
+		return
+	}
+
+	if unknown := substitutions.UnknownDiets(request.DietaryTags); len(unknown) > 0 {
+		ctx.JSON(
+			http.StatusBadRequest,
+			map[string]interface{}{"error": "unsupported dietary tag " + unknown[0] + ", expected one of " + strings.Join(substitutions.Diets, ", ")},
+		)
+		return
+	}
+
+	substitution, err := substitutions.Save(request)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on saving the substitution of %s with %s", request.Ingredient, request.Substitute)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, substitution)
+}
+
+func DeleteIngredientSubstitution(ctx *gin.Context) {
+	substitutionId, ok := ctx.Params.Get("id")
+
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "substitution id was not found"})
+		return
+	}
+
+	substitutionIdAsNumber, err := strconv.Atoi(substitutionId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = substitutions.Delete(substitutionIdAsNumber)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such substitution"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on delete attempt for substitution %s", substitutionId)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"recipes-v2-server/internal/uploads"
+	"recipes-v2-server/utils"
+	"strconv"
+	"strings"
+)
+
+const tusUploadsRoute = "/tus/uploads"
+
+func CreateTusUpload(ginCtx *gin.Context) {
+	claims, err := getTusRequestClaims(ginCtx)
+	if err != nil {
+		return
+	}
+
+	length, err := strconv.ParseInt(ginCtx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters, expected a numeric Upload-Length header"})
+		return
+	}
+
+	kind := parseTusMetadata(ginCtx.GetHeader("Upload-Metadata"))["kind"]
+
+	upload, err := uploads.CreateTus(claims.Id, kind, length)
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on creating a resumable upload for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+
+	setTusProgressHeaders(ginCtx, upload)
+	ginCtx.Header("Location", tusUploadsRoute+"/"+upload.Id)
+	ginCtx.Header("Upload-Key", upload.Key)
+	ginCtx.Status(http.StatusCreated)
+}
+
+func GetTusUploadOffset(ginCtx *gin.Context) {
+	claims, err := getTusRequestClaims(ginCtx)
+	if err != nil {
+		return
+	}
+
+	upload, err := uploads.GetTus(claims.Id, ginCtx.Param("id"))
+	if err != nil {
+		handleTusUploadError(ginCtx, err, ginCtx.Param("id"))
+		return
+	}
+
+	setTusProgressHeaders(ginCtx, upload)
+	ginCtx.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	ginCtx.Header("Upload-Key", upload.Key)
+	ginCtx.Header("Cache-Control", "no-store")
+	ginCtx.Status(http.StatusOK)
+}
+
+func PatchTusUpload(ginCtx *gin.Context) {
+	claims, err := getTusRequestClaims(ginCtx)
+	if err != nil {
+		return
+	}
+
+	if ginCtx.ContentType() != "application/offset+octet-stream" {
+		ginCtx.JSON(http.StatusUnsupportedMediaType, map[string]interface{}{"error": "expected application/offset+octet-stream content"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(ginCtx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters, expected a numeric Upload-Offset header"})
+		return
+	}
+
+	upload, err := uploads.WriteTusChunk(claims.Id, ginCtx.Param("id"), offset, ginCtx.Request.Body)
+	if upload.Id != "" {
+		setTusProgressHeaders(ginCtx, upload)
+	}
+	if err != nil {
+		handleTusUploadError(ginCtx, err, ginCtx.Param("id"))
+		return
+	}
+	ginCtx.Status(http.StatusNoContent)
+}
+
+func DeleteTusUpload(ginCtx *gin.Context) {
+	claims, err := getTusRequestClaims(ginCtx)
+	if err != nil {
+		return
+	}
+
+	err = uploads.TerminateTus(claims.Id, ginCtx.Param("id"))
+	if err != nil {
+		handleTusUploadError(ginCtx, err, ginCtx.Param("id"))
+		return
+	}
+	ginCtx.Status(http.StatusNoContent)
+}
+
+// getTusRequestClaims answers the requests of clients that speak another version of the protocol and of
+// unauthenticated users. Every tus response carries the protocol version.
+func getTusRequestClaims(ginCtx *gin.Context) (claims *utils.TokenClaims, err error) {
+	ginCtx.Header("Tus-Resumable", uploads.TusVersion)
+
+	if ginCtx.GetHeader("Tus-Resumable") != uploads.TusVersion {
+		ginCtx.Header("Tus-Version", uploads.TusVersion)
+		ginCtx.JSON(http.StatusPreconditionFailed, map[string]interface{}{"error": "unsupported tus version"})
+		return nil, errors.New("unsupported tus version")
+	}
+
+	claims, err = getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+	}
+	return
+}
+
+func setTusProgressHeaders(ginCtx *gin.Context, upload uploads.TusUpload) {
+	ginCtx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	ginCtx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+func handleTusUploadError(ginCtx *gin.Context, err error, id string) {
+	var maxBytesError *http.MaxBytesError
+
+	switch {
+	case respondToImageValidationError(ginCtx, err):
+	case err.Error() == "sql: no rows in result set":
+		ginCtx.JSON(http.StatusNotFound, map[string]interface{}{"error": "no such upload or it expired"})
+	case errors.Is(err, uploads.ErrTusOffsetMismatch):
+		ginCtx.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error()})
+	case errors.Is(err, uploads.ErrTusLengthExceeded), errors.As(err, &maxBytesError):
+		ginCtx.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{"error": uploads.ErrTusLengthExceeded.Error()})
+	case errors.Is(err, uploads.ErrTusInterrupted):
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": uploads.ErrTusInterrupted.Error()})
+	default:
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on resumable upload %s", id)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+	}
+}
+
+// parseTusMetadata decodes the Upload-Metadata header - comma separated keys with base64 encoded values
+func parseTusMetadata(header string) map[string]string {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		key, encodedValue, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(encodedValue)
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(value)
+	}
+	return metadata
+}
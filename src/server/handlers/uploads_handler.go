@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	validator "github.com/asaskevich/govalidator"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/fs"
+	"net/http"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/internal/uploads"
+	"recipes-v2-server/internal/users"
+	"recipes-v2-server/storage"
+	"recipes-v2-server/utils"
+	"strings"
+)
+
+func PresignUpload(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request := uploads.PresignRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	upload, err := uploads.Presign(claims.Id, request)
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on presigning an upload for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, upload)
+}
+
+func ConfirmUpload(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request := uploads.ConfirmRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	var variants images.Variants
+
+	err = uploads.Confirm(claims.Id, request.Key, request.Kind, func(content io.Reader) (err error) {
+		switch request.Kind {
+		case "avatar":
+			variants, err = users.UploadAvatarImage(content, claims.Username)
+		case "cover":
+			variants, err = users.UploadCoverImage(content, claims.Username)
+		default:
+			variants, err = recipes.UploadRecipeImage(content, claims.Id)
+		}
+		return
+	})
+	if err != nil {
+		handleConfirmUploadError(ginCtx, err, request.Key)
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"imageURL": variants.Full, "variants": variants})
+}
+
+func ConfirmRecipeImageUpload(ginCtx *gin.Context) {
+	recipeName := ginCtx.Param("name")
+
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	request := uploads.ConfirmRecipeImageRequest{}
+
+	if err = ginCtx.ShouldBind(&request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(request); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	var recipeImage recipes.RecipeImage
+
+	err = uploads.Confirm(claims.Id, request.Key, "recipe", func(content io.Reader) (err error) {
+		recipeImage, err = recipes.AddImage(recipeName, content, request.AltText)
+		return
+	})
+	if err != nil {
+		handleConfirmUploadError(ginCtx, err, request.Key)
+		return
+	}
+	ginCtx.JSON(http.StatusCreated, recipeImage)
+}
+
+func handleConfirmUploadError(ginCtx *gin.Context, err error, key string) {
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
+	if err.Error() == "sql: no rows in result set" {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such pending upload or it expired"})
+		return
+	}
+
+	utils.
+		GetLogger().
+		WithFields(log.Fields{"error": err.Error()}).
+		Errorf("Error on confirming upload %s", key)
+
+	ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+}
+
+// GetStoredFile serves the processed images of the local storage. The content type is the stored one and the clients
+// are told not to sniff another one from the content.
+func GetStoredFile(ginCtx *gin.Context) {
+	key := strings.TrimPrefix(ginCtx.Param("filepath"), "/")
+
+	file, contentType, err := storage.OpenServed(key)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			ginCtx.Status(http.StatusNotFound)
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on serving the stored file %s", key)
+
+		ginCtx.Status(http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		ginCtx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ginCtx.Header("Content-Type", contentType)
+	ginCtx.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(ginCtx.Writer, ginCtx.Request, "", info.ModTime(), file)
+}
+
+// PutPresignedUpload receives the direct uploads when the files are kept in the local storage
+func PutPresignedUpload(ginCtx *gin.Context) {
+	key := strings.TrimPrefix(ginCtx.Param("filepath"), "/")
+
+	err := storage.PutPresigned(key, ginCtx.GetHeader("Content-Type"), ginCtx.Request.URL.Query(), ginCtx.Request.Body)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidPresignedUpload) {
+			ginCtx.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+			return
+		}
+
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			ginCtx.JSON(http.StatusRequestEntityTooLarge, map[string]interface{}{"error": "the uploaded file is too large"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on storing the direct upload %s", key)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.Status(http.StatusOK)
+}
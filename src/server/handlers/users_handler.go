@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	validator "github.com/asaskevich/govalidator"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
+	"mime"
 	"net/http"
+	"recipes-v2-server/internal/exports"
 	"recipes-v2-server/internal/users"
 	"recipes-v2-server/utils"
 	"strconv"
@@ -37,6 +40,46 @@ func GetUser(ginCtx *gin.Context) {
 	ginCtx.JSON(http.StatusOK, user)
 }
 
+func ExportUserData(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	username, ok := ginCtx.Params.Get("username")
+
+	if !ok {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "username was not found"})
+		return
+	}
+
+	export, err := exports.ExportUserData(username, claims.Id)
+	if err != nil {
+		if errors.Is(err, exports.ErrNotOwner) {
+			ginCtx.JSON(http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+			return
+		}
+
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such user"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on exporting the data of user %s", username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+
+	ginCtx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": username + "-data.json"}))
+	ginCtx.Header("Cache-Control", "no-store")
+	ginCtx.JSON(http.StatusOK, export)
+}
+
 func UploadCoverImage(ginCtx *gin.Context) {
 	username, found := ginCtx.GetPostForm("username")
 	if !found {
@@ -58,7 +101,17 @@ func UploadCoverImage(ginCtx *gin.Context) {
 		return
 	}
 
-	imageURL, err := users.UploadCoverImage(coverImage, imageKey, username)
+	coverImageContent, err := coverImage.Open()
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the uploaded file could not be read"})
+		return
+	}
+	defer coverImageContent.Close()
+
+	variants, err := users.UploadCoverImage(coverImageContent, username)
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
 	if err != nil {
 		utils.
 			GetLogger().
@@ -68,7 +121,7 @@ func UploadCoverImage(ginCtx *gin.Context) {
 		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
 		return
 	}
-	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"coverImageURL": imageURL})
+	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"coverImageURL": variants.Full, "variants": variants})
 }
 
 func UploadAvatarImage(ginCtx *gin.Context) {
@@ -92,7 +145,17 @@ func UploadAvatarImage(ginCtx *gin.Context) {
 		return
 	}
 
-	imageURL, err := users.UploadAvatarImage(avatarImage, imageKey, username)
+	avatarImageContent, err := avatarImage.Open()
+	if err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "the uploaded file could not be read"})
+		return
+	}
+	defer avatarImageContent.Close()
+
+	variants, err := users.UploadAvatarImage(avatarImageContent, username)
+	if respondToImageValidationError(ginCtx, err) {
+		return
+	}
 	if err != nil {
 		utils.
 			GetLogger().
@@ -102,7 +165,7 @@ func UploadAvatarImage(ginCtx *gin.Context) {
 		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
 		return
 	}
-	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"avatarImageURL": imageURL})
+	ginCtx.JSON(http.StatusCreated, map[string]interface{}{"avatarImageURL": variants.Full, "variants": variants})
 }
 
 func EditUserData(ginCtx *gin.Context) {
@@ -208,6 +271,52 @@ func DeleteUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
 
+func GetDeletedUsers(ctx *gin.Context) {
+	deleted, err := users.GetDeleted()
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Error("Error on getting the deleted users")
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, deleted)
+}
+
+func RestoreUser(ctx *gin.Context) {
+	userId, ok := ctx.Params.Get("id")
+
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": "user id was not found"})
+		return
+	}
+
+	userIdAsNumber, err := strconv.Atoi(userId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{"errors": err.Error()})
+		return
+	}
+
+	err = users.Restore(userIdAsNumber)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ctx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such deleted user"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on restore attempt for user %s", userId)
+
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
 func ChangeRole(ctx *gin.Context) {
 	var data users.UserChangeRoleData
 
@@ -301,3 +410,82 @@ func UnblockUser(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
 }
+
+func GetHiddenUsers(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	hiddenUsers, err := users.GetHidden(claims.Id)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on getting hidden users for user %s", claims.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, hiddenUsers)
+}
+
+func HideUser(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	var data users.HideUserData
+
+	if err = ginCtx.ShouldBind(&data); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "invalid parameters"})
+		return
+	}
+
+	if _, err = validator.ValidateStruct(data); err != nil {
+		ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	err = users.Hide(claims.Id, data.Username)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			ginCtx.JSON(http.StatusBadRequest, map[string]interface{}{"error": "no such user found"})
+			return
+		}
+
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on hide attempt for user %s", data.Username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+func UnhideUser(ginCtx *gin.Context) {
+	claims, err := getRequestClaims(ginCtx)
+	if err != nil {
+		ginCtx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	username := ginCtx.Param("username")
+
+	err = users.Unhide(claims.Id, username)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on unhide attempt for user %s", username)
+
+		ginCtx.JSON(http.StatusInternalServerError, map[string]interface{}{})
+		return
+	}
+	ginCtx.JSON(http.StatusOK, map[string]interface{}{"status": "success"})
+}
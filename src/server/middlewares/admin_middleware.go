@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 	"net/http"
 	"recipes-v2-server/utils"
 )
@@ -27,6 +28,27 @@ func AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		isDeleted, err := isDeletedUser(claims.Id)
+		if err != nil {
+			utils.
+				GetLogger().
+				WithFields(log.Fields{"error": err.Error()}).
+				Errorf("Error on checking if user %d is deleted", claims.Id)
+
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, map[string]interface{}{})
+			return
+		}
+
+		if isDeleted {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, Errors{
+				Info: Info{
+					Message: "Invalid Token",
+					Cause:   "Auth Token",
+				},
+			})
+			return
+		}
+
 		if claims.Role != "ADMINISTRATOR" {
 			ctx.AbortWithStatusJSON(http.StatusForbidden, Errors{Info: Info{Message: "You don't have permissions to access this resource", Cause: "Missing permissions"}})
 			return
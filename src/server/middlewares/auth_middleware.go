@@ -2,7 +2,9 @@ package middlewares
 
 import (
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 	"net/http"
+	"recipes-v2-server/database"
 	"recipes-v2-server/utils"
 )
 
@@ -16,7 +18,7 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		token := ctx.Request.Header["X-Authorization"][0]
 
-		_, isValid, err := utils.ParseJWT(token)
+		claims, isValid, err := utils.ParseJWT(token)
 		if err != nil || !isValid {
 			ctx.AbortWithStatusJSON(http.StatusForbidden, Errors{
 				Info: Info{
@@ -27,6 +29,38 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		isDeleted, err := isDeletedUser(claims.Id)
+		if err != nil {
+			utils.
+				GetLogger().
+				WithFields(log.Fields{"error": err.Error()}).
+				Errorf("Error on checking if user %d is deleted", claims.Id)
+
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, map[string]interface{}{})
+			return
+		}
+
+		if isDeleted {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, Errors{
+				Info: Info{
+					Message: "Invalid Token",
+					Cause:   "Auth token",
+				},
+			})
+			return
+		}
+
 		ctx.Next()
 	}
 }
+
+// isDeletedUser checks if the user of the token was deleted after the token was issued. The tokens of the deleted
+// users are not revoked, so they are rejected here until they expire.
+func isDeletedUser(id int) (isDeleted bool, err error) {
+	err = database.GetSingleRecordNamedQuery(
+		&isDeleted,
+		`SELECT NOT EXISTS(SELECT id FROM users WHERE id = :id AND deleted_at IS NULL);`,
+		map[string]interface{}{"id": id},
+	)
+	return
+}
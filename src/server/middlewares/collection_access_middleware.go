@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"recipes-v2-server/internal/collections"
+	"recipes-v2-server/utils"
+	"strconv"
+)
+
+// CollectionOwnerMiddleware checks if the request comes from the collection owner or ADMINISTRATOR and only then
+// the request is authorized
+func CollectionOwnerMiddleware() gin.HandlerFunc {
+	return collectionAccessMiddleware(collections.IsOwner)
+}
+
+// CollectionCollaboratorMiddleware checks if the request comes from the collection owner, one of its collaborators
+// or ADMINISTRATOR and only then the request is authorized
+func CollectionCollaboratorMiddleware() gin.HandlerFunc {
+	return collectionAccessMiddleware(collections.CanAddRecipes)
+}
+
+func collectionAccessMiddleware(hasAccess func(id, userId int) (bool, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if len(ctx.Request.Header["X-Authorization"]) == 0 || len(ctx.Request.Header["X-Authorization"][0]) == 0 {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		token := ctx.Request.Header["X-Authorization"][0]
+
+		claims, isValid, err := utils.ParseJWT(token)
+		if err != nil || !isValid {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, Errors{
+				Info: Info{
+					Message: "Invalid Token",
+					Cause:   "Auth Token",
+				},
+			})
+			return
+		}
+
+		collectionId, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, Errors{Info: Info{Message: "Missing identifier", Cause: "Failed to identify resource"}})
+			return
+		}
+
+		if claims.Role == "ADMINISTRATOR" {
+			ctx.Next()
+			return
+		}
+
+		permissionsAreValid, err := hasAccess(collectionId, claims.Id)
+		if err != nil || !permissionsAreValid {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, Errors{Info: Info{Message: "You don't have permissions to access this resource", Cause: "Missing permissions"}})
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,14 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"recipes-v2-server/storage"
+)
+
+// ImmutableCacheMiddleware lets the clients cache the stored files forever, since the content of a key never changes
+func ImmutableCacheMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Writer.Header().Set("Cache-Control", storage.ImmutableCacheControl)
+		ctx.Next()
+	}
+}
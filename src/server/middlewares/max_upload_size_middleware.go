@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// multipartOverhead is the room left for the boundaries and the other form fields of a multipart upload
+const multipartOverhead = 64 << 10
+
+// MaxUploadSizeMiddleware rejects uploads with a body over the given file size. The size is enforced while the body
+// is read, so a client that sends a wrong Content-Length is cut off as well. Multipart forms are parsed here, so a
+// body that is too large or broken off is reported before the handlers look for their form fields.
+func MaxUploadSizeMiddleware(maxFileBytes int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		maxBytes := maxFileBytes + multipartOverhead
+		if ctx.Request.ContentLength > maxBytes {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, map[string]interface{}{"error": "the uploaded file is too large"})
+			return
+		}
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes)
+
+		if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+			if _, err := ctx.MultipartForm(); err != nil {
+				var maxBytesError *http.MaxBytesError
+				if errors.As(err, &maxBytesError) {
+					ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, map[string]interface{}{"error": "the uploaded file is too large"})
+					return
+				}
+
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{"error": "the upload was incomplete or malformed"})
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
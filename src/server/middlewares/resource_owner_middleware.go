@@ -84,7 +84,7 @@ func validateCommentEditRequest(claims *utils.TokenClaims, ctx *gin.Context) (va
 
 		err := database.GetSingleRecordNamedQuery(
 			&ownerId,
-			`SELECT owner_id FROM comments WHERE id = :id`,
+			`SELECT owner_id FROM comments WHERE id = :id AND deleted_at IS NULL`,
 			map[string]interface{}{"id": editData.Id},
 		)
 		if err != nil {
@@ -104,7 +104,7 @@ func validateCommentEditRequest(claims *utils.TokenClaims, ctx *gin.Context) (va
 
 		selectErr := database.GetSingleRecordNamedQuery(
 			&ownerId,
-			`SELECT owner_id FROM comments WHERE id = :id`,
+			`SELECT owner_id FROM comments WHERE id = :id AND deleted_at IS NULL`,
 			map[string]interface{}{"id": deleteData.Id},
 		)
 		if selectErr != nil {
@@ -125,7 +125,7 @@ func validateRecipeEditRequest(claims *utils.TokenClaims, recipeName string) (va
 
 	err := database.GetSingleRecordNamedQuery(
 		&ownerId,
-		`SELECT owner_id FROM recipes WHERE recipe_name = :recipe_name`,
+		`SELECT owner_id FROM recipes WHERE recipe_name = :recipe_name AND deleted_at IS NULL`,
 		map[string]interface{}{"recipe_name": recipeName},
 	)
 	if err != nil {
@@ -144,7 +144,7 @@ func validateUserEditRequest(claims *utils.TokenClaims, username string) (validP
 
 	err := database.GetSingleRecordNamedQuery(
 		&ownerId,
-		`SELECT id FROM users WHERE username = :username`,
+		`SELECT id FROM users WHERE username = :username AND deleted_at IS NULL`,
 		map[string]interface{}{"username": username},
 	)
 	if err != nil {
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/nleeper/goment"
 	log "github.com/sirupsen/logrus"
@@ -10,15 +11,24 @@ import (
 	"time"
 )
 
+// recipeViewWindow is the period in which repeated views of a recipe from the same visitor are counted only once
+const recipeViewWindow = "30 minutes"
+
+// botUserAgentMarkers are parts of the user agents of crawlers, link previews and scripts which views are not counted
+var botUserAgentMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "facebookexternalhit", "preview", "headless", "lighthouse",
+	"curl", "wget", "python-requests", "go-http-client", "okhttp", "postman",
+}
+
 // TrackVisitations tracks every unique website visitation and adds the authenticated users IP to the database
-// IP list
+// IP list. Recipe views are counted once per visitor in a time window and requests from known bots are ignored.
 func TrackVisitations() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		clientIP := ctx.ClientIP()
 
 		referer := ctx.Request.Referer()
 
-		if referer != "https://all-the-best-recipes.vercel.app/" {
+		if referer != "https://all-the-best-recipes.vercel.app/" || isBot(ctx.Request.UserAgent()) {
 			return
 		}
 
@@ -30,28 +40,66 @@ func TrackVisitations() gin.HandlerFunc {
 
 		collectVisitationToVisitationsTable(date, clientIP)
 
+		var token string
 		if len(ctx.Request.Header["X-Authorization"]) != 0 && len(ctx.Request.Header["X-Authorization"][0]) != 0 {
-			token := ctx.Request.Header["X-Authorization"][0]
+			token = ctx.Request.Header["X-Authorization"][0]
 			collectIPtoAuthenticatedUsersIPsList(clientIP, token)
 		}
 
-		isRecipeEndpoint := strings.Contains(ctx.Request.URL.String(), "/recipes")
+		isRecipePage := ctx.FullPath() == "/recipes/:name"
 		isGETRequest := ctx.Request.Method == "GET"
-		recipeName, recipeNameParamExists := ctx.Params.Get("name")
+		recipeName := ctx.Param("name")
+
+		if isRecipePage && isGETRequest {
+			visitorKey := "ip:" + clientIP
 
-		if isRecipeEndpoint && isGETRequest && recipeNameParamExists {
-			addANewRecipeVisitation(recipeName)
+			if token != "" {
+				if claims, isValid, err := utils.ParseJWT(token); err == nil && isValid {
+					visitorKey = fmt.Sprintf("user:%d", claims.Id)
+				}
+				collectUserRecipeView(recipeName, token)
+			}
+
+			addANewRecipeVisitation(recipeName, visitorKey)
 		}
 		ctx.Next()
 	}
 }
 
-func addANewRecipeVisitation(recipeName string) {
+func isBot(userAgent string) bool {
+	if userAgent == "" {
+		return true
+	}
+
+	userAgent = strings.ToLower(userAgent)
+	for _, marker := range botUserAgentMarkers {
+		if strings.Contains(userAgent, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// addANewRecipeVisitation counts a view in the daily recipe views, unless the same visitor has already viewed the
+// recipe within the view window. The counts are added to the recipe visitations count by a periodic job, so the
+// recipes table is not updated on every page load.
+func addANewRecipeVisitation(recipeName, visitorKey string) {
 	_, err := database.ExecuteNamedQuery(
-		`UPDATE recipes
-				SET visitations_count = visitations_count + 1
-				WHERE recipe_name = :recipe_name;`,
-		map[string]interface{}{"recipe_name": recipeName},
+		`WITH recipe AS (SELECT id FROM recipes WHERE recipe_name = :recipe_name AND deleted_at IS NULL),
+
+					 counted_view AS (INSERT INTO recipe_view_visitors (recipe_id, visitor_key, last_viewed_at)
+						 SELECT id, :visitor_key, NOW()
+						 FROM recipe
+						 ON CONFLICT (recipe_id, visitor_key) DO UPDATE SET last_viewed_at = EXCLUDED.last_viewed_at
+							 WHERE recipe_view_visitors.last_viewed_at < NOW() - CAST(:window AS INTERVAL)
+						 RETURNING recipe_id)
+
+				INSERT
+				INTO recipe_daily_views (recipe_id, day, views)
+				SELECT recipe_id, CURRENT_DATE, 1
+				FROM counted_view
+				ON CONFLICT (recipe_id, day) DO UPDATE SET views = recipe_daily_views.views + 1;`,
+		map[string]interface{}{"recipe_name": recipeName, "visitor_key": visitorKey, "window": recipeViewWindow},
 	)
 	if err != nil {
 		utils.
@@ -62,6 +110,29 @@ func addANewRecipeVisitation(recipeName string) {
 	}
 }
 
+// collectUserRecipeView remembers which recipes the authenticated user has seen, used for the personalized feed
+func collectUserRecipeView(recipeName, token string) {
+	claims, isValid, err := utils.ParseJWT(token)
+	if err != nil || !isValid {
+		return
+	}
+
+	_, err = database.ExecuteNamedQuery(
+		`INSERT INTO user_recipe_views (user_id, recipe_id, viewed_at)
+				SELECT :user_id, id, NOW()
+				FROM recipes
+				WHERE recipe_name = :recipe_name AND deleted_at IS NULL
+				ON CONFLICT (user_id, recipe_id) DO UPDATE SET viewed_at = EXCLUDED.viewed_at;`,
+		map[string]interface{}{"user_id": claims.Id, "recipe_name": recipeName},
+	)
+	if err != nil {
+		utils.
+			GetLogger().
+			WithFields(log.Fields{"error": err.Error()}).
+			Errorf("Error on track recipe view attempt for user %s", claims.Username)
+	}
+}
+
 func collectIPtoAuthenticatedUsersIPsList(ip string, token string) {
 	claims, isValid, err := utils.ParseJWT(token)
 	if err != nil || !isValid {
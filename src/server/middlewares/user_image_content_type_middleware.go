@@ -3,11 +3,12 @@ package middlewares
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
-	"strings"
+	"recipes-v2-server/internal/images"
 )
 
-// ImageContentTypeMiddleware checks for valid content type header of images
+// ImageContentTypeMiddleware checks that the uploaded user image is of a supported image type
 func ImageContentTypeMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		username, found := ctx.GetPostForm("username")
@@ -34,9 +35,16 @@ func ImageContentTypeMiddleware() gin.HandlerFunc {
 			}
 		}
 
-		contentTypeIsOfTypeImage := strings.Contains(file.Header.Get("Content-Type"), "image/")
+		// the content type sent by the client can not be trusted, so the file is sniffed by its magic bytes. The
+		// full validation happens when the image is processed.
+		header := make([]byte, 16)
+		fileContent, err := file.Open()
+		if err == nil {
+			_, err = io.ReadFull(fileContent, header)
+			_ = fileContent.Close()
+		}
 
-		if !contentTypeIsOfTypeImage {
+		if _, sniffErr := images.SniffFormat(header); err != nil || sniffErr != nil {
 			ctx.AbortWithStatusJSON(
 				http.StatusBadRequest,
 				map[string]interface{}{"message": "provided file can only be of type image"},
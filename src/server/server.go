@@ -2,20 +2,29 @@ package server
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
 	"github.com/olahol/melody"
 	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
 	"recipes-v2-server/database"
+	"recipes-v2-server/internal/images"
+	"recipes-v2-server/internal/notifications"
+	"recipes-v2-server/internal/recipes"
+	"recipes-v2-server/internal/trash"
+	"recipes-v2-server/internal/uploads"
 	"recipes-v2-server/server/handlers"
 	"recipes-v2-server/server/middlewares"
+	"recipes-v2-server/storage"
 	"recipes-v2-server/utils"
 	"time"
 )
 
+// websocket sends the realtime notifications, also the ones created by the jobs
+var websocket = melody.New()
+
 func setupRouter() (router *gin.Engine) {
 	gin.SetMode(gin.ReleaseMode)
 	router = gin.New()
-	websocket := melody.New()
 
 	router.Use(middlewares.Logger(utils.GetLogger()), gin.Recovery())
 	router.Use(middlewares.CORS())
@@ -25,11 +34,26 @@ func setupRouter() (router *gin.Engine) {
 	router.GET("/healths", handlers.HealthCheck)
 	router.GET("/metrics", handlers.Metrics)
 
+	if route, _, isLocal := storage.LocalRoute(); isLocal {
+		router.GET(route+"/*filepath", middlewares.ImmutableCacheMiddleware(), handlers.GetStoredFile)
+		router.HEAD(route+"/*filepath", middlewares.ImmutableCacheMiddleware(), handlers.GetStoredFile)
+		router.PUT(route+"/*filepath", middlewares.MaxUploadSizeMiddleware(uploads.MaxUploadSize()), handlers.PutPresignedUpload)
+	}
+
 	router.GET("/recipes", handlers.GetAllRecipes)
 	router.GET("/recipes/category", handlers.GetByCategory)
 	router.GET("/recipes/latest", handlers.GetLatestRecipes)
 	router.GET("/recipes/most-popular", handlers.GetMostPopularRecipes)
+	router.GET("/recipes/trending", handlers.GetTrendingRecipes)
 	router.GET("/recipes/:name", handlers.GetRecipe)
+	router.GET("/recipes/:name/forks", handlers.GetRecipeForks)
+	router.GET("/recipes/:name/cook", handlers.GetRecipeCookMode)
+	router.GET("/recipes/:name/similar", handlers.GetSimilarRecipes)
+	router.GET("/recipes/:name/images", handlers.GetRecipeImages)
+	router.GET("/recipes/:name/translations", handlers.GetRecipeTranslations)
+	router.GET("/recipes/:name/cook-logs", handlers.GetRecipeCookLogs)
+	router.GET("/recipes/:name/cook-logs/photos", handlers.GetRecipeCookLogPhotos)
+	router.GET("/recipes/:name/substitutions", handlers.GetRecipeSubstitutions)
 	router.GET("/recipes/user/:username", handlers.GetRecipesByUser)
 	router.GET("/recipes/favourites/:username", handlers.GetUserFavouriteRecipes)
 	router.POST("/recipes/is-favourite", handlers.CheckIfRecipeIsInFavourites)
@@ -37,6 +61,11 @@ func setupRouter() (router *gin.Engine) {
 
 	router.GET("/users/:username", handlers.GetUser)
 
+	router.GET("/meal-plans/calendar.ics", handlers.GetMealPlanCalendar)
+
+	router.GET("/collections/user/:username", handlers.GetUserCollections)
+	router.GET("/collections/:id", handlers.GetCollection)
+
 	router.GET("/comments/latest", handlers.GetLatestComments)
 	router.GET("/comments/:recipeName", handlers.GetRecipeComments)
 
@@ -61,18 +90,61 @@ func setupRouter() (router *gin.Engine) {
 		authGroup.GET("/notifications/:username", handlers.GetNotifications)
 		authGroup.PUT("/notifications", handlers.MarkNotificationAsRead)
 
+		authGroup.GET("/recipes/feed", handlers.GetRecipesFeed)
 		authGroup.POST("/recipes/add-to-favourites", handlers.AddToFavourites)
 		authGroup.DELETE("/recipes/remove-from-favourites", handlers.RemoveFromFavourites)
 		authGroup.POST("/recipes", handlers.CreateRecipe)
-		authGroup.POST("/recipes/upload-image", handlers.UploadRecipeImage)
+		authGroup.POST("/recipes/upload-image", middlewares.MaxUploadSizeMiddleware(images.RecipeImage.MaxBytes), handlers.UploadRecipeImage)
+		authGroup.POST("/recipes/:name/fork", handlers.ForkRecipe)
+		authGroup.POST("/recipes/:name/cook-logs", middlewares.MaxUploadSizeMiddleware(images.CookLogPhoto.MaxBytes), handlers.CreateCookLog(websocket))
+		authGroup.DELETE("/cook-logs/:id", handlers.DeleteCookLog)
+		authGroup.GET("/recipes/:name/notes", handlers.GetRecipeNotes)
+		authGroup.POST("/recipes/:name/notes", handlers.CreateRecipeNote)
+		authGroup.PUT("/recipes/:name/notes/:id", handlers.EditRecipeNote)
+		authGroup.DELETE("/recipes/:name/notes/:id", handlers.DeleteRecipeNote)
 
 		authGroup.POST("/comments", handlers.CreateComment)
 
+		authGroup.POST("/collections", handlers.CreateCollection)
+
+		// the export holds the private notes, so unlike the other user routes it is only for the user themselves
+		authGroup.GET("/users/:username/export", handlers.ExportUserData)
+
+		authGroup.GET("/hidden-users", handlers.GetHiddenUsers)
+		authGroup.POST("/hidden-users", handlers.HideUser)
+		authGroup.DELETE("/hidden-users/:username", handlers.UnhideUser)
+
+		authGroup.GET("/meal-plans", handlers.GetMealPlan)
+		authGroup.GET("/meal-plans/nutrition", handlers.GetMealPlanNutrition)
+		authGroup.POST("/meal-plans", handlers.AddMealPlanEntry)
+		authGroup.POST("/meal-plans/copy-week", handlers.CopyMealPlanWeek)
+		authGroup.POST("/meal-plans/calendar-token", handlers.CreateMealPlanCalendarToken)
+		authGroup.DELETE("/meal-plans/calendar-token", handlers.RevokeMealPlanCalendarToken)
+		authGroup.DELETE("/meal-plans/:id", handlers.DeleteMealPlanEntry)
+
+		authGroup.POST("/presigned-uploads", handlers.PresignUpload)
+		authGroup.POST("/presigned-uploads/confirm", handlers.ConfirmUpload)
+
+		authGroup.POST("/tus/uploads", handlers.CreateTusUpload)
+		authGroup.HEAD("/tus/uploads/:id", handlers.GetTusUploadOffset)
+		authGroup.PATCH("/tus/uploads/:id", middlewares.MaxUploadSizeMiddleware(uploads.MaxUploadSize()), handlers.PatchTusUpload)
+		authGroup.DELETE("/tus/uploads/:id", handlers.DeleteTusUpload)
+
+		// the body size is limited before the content type middleware parses the form
 		imageUploadGroup := authGroup.Group("/upload/image/users")
-		imageUploadGroup.Use(middlewares.ImageContentTypeMiddleware())
 		{
-			imageUploadGroup.POST("/cover-image", handlers.UploadCoverImage)
-			imageUploadGroup.POST("/avatar-image", handlers.UploadAvatarImage)
+			imageUploadGroup.POST(
+				"/cover-image",
+				middlewares.MaxUploadSizeMiddleware(images.CoverImage.MaxBytes),
+				middlewares.ImageContentTypeMiddleware(),
+				handlers.UploadCoverImage,
+			)
+			imageUploadGroup.POST(
+				"/avatar-image",
+				middlewares.MaxUploadSizeMiddleware(images.Avatar.MaxBytes),
+				middlewares.ImageContentTypeMiddleware(),
+				handlers.UploadAvatarImage,
+			)
 		}
 	}
 
@@ -82,10 +154,38 @@ func setupRouter() (router *gin.Engine) {
 		resourceOwnerGroup.PATCH("/users/:username", handlers.EditUserData)
 		resourceOwnerGroup.PUT("/recipes/:name", handlers.EditRecipe)
 		resourceOwnerGroup.DELETE("/recipes/:name", handlers.DeleteRecipe)
+		resourceOwnerGroup.POST("/recipes/:name/publish", handlers.PublishRecipe)
+		resourceOwnerGroup.POST("/recipes/:name/images", middlewares.MaxUploadSizeMiddleware(images.RecipeImage.MaxBytes), handlers.AddRecipeImage)
+		resourceOwnerGroup.POST("/recipes/:name/images/confirm", handlers.ConfirmRecipeImageUpload)
+		resourceOwnerGroup.PUT("/recipes/:name/images/order", handlers.ReorderRecipeImages)
+		resourceOwnerGroup.PUT("/recipes/:name/images/:id", handlers.EditRecipeImage)
+		resourceOwnerGroup.DELETE("/recipes/:name/images/:id", handlers.DeleteRecipeImage)
+		resourceOwnerGroup.POST("/recipes/:name/steps/:step/image", middlewares.MaxUploadSizeMiddleware(images.StepImage.MaxBytes), handlers.UploadRecipeStepImage)
+		resourceOwnerGroup.DELETE("/recipes/:name/steps/:step/image", handlers.DeleteRecipeStepImage)
+		resourceOwnerGroup.PUT("/recipes/:name/translations/:lang", handlers.SaveRecipeTranslation)
+		resourceOwnerGroup.DELETE("/recipes/:name/translations/:lang", handlers.DeleteRecipeTranslation)
 		resourceOwnerGroup.PUT("/comments", handlers.EditComment)
 		resourceOwnerGroup.DELETE("/comments", handlers.DeleteComment)
 	}
 
+	collectionOwnerGroup := router.Group("/collections/:id")
+	collectionOwnerGroup.Use(middlewares.CollectionOwnerMiddleware())
+	{
+		collectionOwnerGroup.PUT("", handlers.EditCollection)
+		collectionOwnerGroup.DELETE("", handlers.DeleteCollection)
+		collectionOwnerGroup.POST("/cover-image", middlewares.MaxUploadSizeMiddleware(images.CoverImage.MaxBytes), handlers.UploadCollectionCoverImage)
+		collectionOwnerGroup.POST("/collaborators", handlers.AddCollectionCollaborator)
+		collectionOwnerGroup.DELETE("/collaborators/:username", handlers.RemoveCollectionCollaborator)
+	}
+
+	collectionCollaboratorGroup := router.Group("/collections/:id/recipes")
+	collectionCollaboratorGroup.Use(middlewares.CollectionCollaboratorMiddleware())
+	{
+		collectionCollaboratorGroup.POST("", handlers.AddRecipeToCollection)
+		collectionCollaboratorGroup.DELETE("", handlers.RemoveRecipeFromCollection)
+		collectionCollaboratorGroup.PUT("/order", handlers.ReorderCollectionRecipes)
+	}
+
 	adminGroup := router.Group("/admin")
 	adminGroup.Use(middlewares.AdminMiddleware())
 	{
@@ -93,10 +193,15 @@ func setupRouter() (router *gin.Engine) {
 		adminGroup.GET("/recipes", handlers.GetAllRecipesAdmin)
 		adminGroup.DELETE("/recipes/:id", handlers.DeleteAdminRecipe)
 		adminGroup.PATCH("/recipes/:id/approve", handlers.ApproveRecipe)
+		adminGroup.GET("/recipes/scheduled", handlers.GetScheduledRecipes)
+		adminGroup.PUT("/recipes/:id/schedule", handlers.ScheduleRecipe)
+		adminGroup.GET("/recipes/:id/duplicates", handlers.GetRecipeDuplicates)
+		adminGroup.PATCH("/recipes/:id/restore", handlers.RestoreRecipe)
 
 		adminGroup.GET("/comments/count", handlers.GetCommentsCount)
 		adminGroup.GET("/comments", handlers.GetAllComments)
 		adminGroup.DELETE("/comments/:id", handlers.DeleteAdminComment)
+		adminGroup.PATCH("/comments/:id/restore", handlers.RestoreComment)
 
 		adminGroup.GET("/users/count", handlers.GetUsersCount)
 		adminGroup.GET("/users", handlers.GetAllUsers)
@@ -104,11 +209,26 @@ func setupRouter() (router *gin.Engine) {
 		adminGroup.PATCH("/users/change-role", handlers.ChangeRole)
 		adminGroup.POST("/users/block", handlers.BlockUser)
 		adminGroup.POST("/users/unblock/:id", handlers.UnblockUser)
+		adminGroup.PATCH("/users/:id/restore", handlers.RestoreUser)
+
+		adminGroup.GET("/trash/recipes", handlers.GetDeletedRecipes)
+		adminGroup.GET("/trash/comments", handlers.GetDeletedComments)
+		adminGroup.GET("/trash/users", handlers.GetDeletedUsers)
 
 		adminGroup.GET("/analytics/visitations", handlers.GetVisitationsForTheLastSixMonths)
 		adminGroup.GET("/analytics/most-active-user", handlers.GetTheMostActiveUser)
 		adminGroup.GET("/analytics/visitations/today", handlers.GetVisitationsForTheDay)
 
+		adminGroup.GET("/images/orphaned", handlers.GetOrphanedImages)
+
+		adminGroup.GET("/ingredient-prices", handlers.GetIngredientPrices)
+		adminGroup.PUT("/ingredient-prices", handlers.SaveIngredientPrice)
+		adminGroup.DELETE("/ingredient-prices/:id", handlers.DeleteIngredientPrice)
+
+		adminGroup.GET("/ingredient-substitutions", handlers.GetIngredientSubstitutions)
+		adminGroup.PUT("/ingredient-substitutions", handlers.SaveIngredientSubstitution)
+		adminGroup.DELETE("/ingredient-substitutions/:id", handlers.DeleteIngredientSubstitution)
+
 		adminGroup.GET("/search", handlers.Search)
 	}
 
@@ -129,6 +249,42 @@ func Run() {
 	if err != nil {
 		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding clean up notifications job")
 	}
+	_, err = cronjob.AddFunc("15 */6 * * *", computeSimilarRecipes)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding compute similar recipes job")
+	}
+	_, err = cronjob.AddFunc("5 * * * *", refreshTrendingRecipes)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding refresh trending recipes job")
+	}
+	_, err = cronjob.AddFunc("*/10 * * * *", syncRecipeVisitationsCount)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding sync recipe visitations job")
+	}
+	_, err = cronjob.AddFunc("30 4 * * *", cleanUpRecipeViewVisitors)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding clean up recipe view visitors job")
+	}
+	_, err = cronjob.AddFunc("50 * * * *", cleanUpExpiredUploads)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding clean up expired uploads job")
+	}
+	_, err = cronjob.AddFunc("0 5 * * *", purgeTrash)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding purge trash job")
+	}
+	_, err = cronjob.AddFunc("20 5 * * *", collectOrphanedImages)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding collect orphaned images job")
+	}
+	_, err = cronjob.AddFunc("45 4 * * *", refreshRecipeCostEstimates)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding refresh recipe cost estimates job")
+	}
+	_, err = cronjob.AddFunc("* * * * *", publishScheduledRecipes)
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error adding publish scheduled recipes job")
+	}
 
 	cronjob.Start()
 
@@ -159,3 +315,89 @@ func cleanUpNotificationsMarkedAsRead() {
 		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing clean up notifications requests job")
 	}
 }
+
+func computeSimilarRecipes() {
+	err := recipes.ComputeSimilarities()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing compute similar recipes job")
+	}
+}
+
+func refreshTrendingRecipes() {
+	err := recipes.RefreshTrendingScores()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing refresh trending recipes job")
+	}
+}
+
+func syncRecipeVisitationsCount() {
+	err := recipes.SyncVisitationsCount()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing sync recipe visitations job")
+	}
+}
+
+func cleanUpRecipeViewVisitors() {
+	err := recipes.CleanUpViewVisitors()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing clean up recipe view visitors job")
+	}
+}
+
+func cleanUpExpiredUploads() {
+	err := uploads.CleanUpExpired()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing clean up expired uploads job")
+	}
+}
+
+func collectOrphanedImages() {
+	err := images.CollectOrphans()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing collect orphaned images job")
+	}
+}
+
+func refreshRecipeCostEstimates() {
+	err := recipes.RefreshCostEstimates()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing refresh recipe cost estimates job")
+	}
+}
+
+func purgeTrash() {
+	err := trash.Purge()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing purge trash job")
+	}
+}
+
+// publishScheduledRecipes approves the scheduled recipes whose time has come and notifies about them as if they were
+// just created
+func publishScheduledRecipes() {
+	published, err := recipes.PublishScheduled()
+	if err != nil {
+		utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Error("Error executing publish scheduled recipes job")
+		return
+	}
+
+	for _, recipe := range published {
+		receivers, err := notifications.Create(notifications.NotificationRequest{
+			SenderAvatar:   recipe.OwnerAvatar.URL(),
+			SenderUsername: recipe.OwnerName,
+			SenderId:       recipe.OwnerId,
+			Action:         "CREATED_RECIPE",
+			LocationName:   recipe.RecipeName,
+			OwnerName:      recipe.OwnerName,
+		})
+		if err != nil {
+			utils.GetLogger().WithFields(log.Fields{"error": err.Error()}).Errorf("Error on notifying about the published recipe %s", recipe.RecipeName)
+			continue
+		}
+
+		receiversUsernames, _ := json.Marshal(receivers)
+		if err = websocket.Broadcast(receiversUsernames); err != nil {
+			utils.GetLogger().WithFields(log.Fields{"warning": err.Error()}).Warn("Error on send receiver ids attempt")
+		}
+	}
+}
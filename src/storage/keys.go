@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ObjectKey is the key of a stored file as it is kept in the DB. It is sent to the clients as the public URL of the
+// file, built at response time, so the storage or CDN domain can change without rewriting the DB. Absolute URLs -
+// external images or rows not migrated to keys yet - are kept as they are.
+type ObjectKey string
+
+var publicBaseURL string
+
+// SetPublicURL sets the base URL the files are served from to the clients, e.g. a CDN in front of the bucket. Falls
+// back to the URL of the storage backend itself when it is empty.
+func SetPublicURL(baseURL string) {
+	publicBaseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// URL builds the public URL of the file
+func (key ObjectKey) URL() string {
+	if key == "" || IsAbsoluteURL(string(key)) {
+		return string(key)
+	}
+	return PublicURL(string(key))
+}
+
+// MarshalJSON sends the key to the clients as the public URL of the file
+func (key ObjectKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(key.URL())
+}
+
+// UnmarshalJSON accepts both keys and the public URLs the clients received, keeping only the key
+func (key *ObjectKey) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*key = ObjectKey(KeyFromURL(value))
+	return nil
+}
+
+// BaseURLs are the URL prefixes the files of the active storage are reachable at - the public base URL and the URL
+// of the storage backend itself
+func BaseURLs() (baseURLs []string) {
+	if publicBaseURL != "" {
+		baseURLs = append(baseURLs, publicBaseURL+"/")
+	}
+	if backend != nil {
+		baseURLs = append(baseURLs, backend.PublicURL(""))
+	}
+	return
+}
+
+// KeyFromURL extracts the key of a file from its URL. URLs that do not belong to the storage are returned unchanged.
+func KeyFromURL(url string) string {
+	for _, baseURL := range BaseURLs() {
+		if strings.HasPrefix(url, baseURL) && len(url) > len(baseURL) {
+			return strings.TrimPrefix(url, baseURL)
+		}
+	}
+	return url
+}
+
+// IsAbsoluteURL checks if the value is an http(s) URL rather than a key. Values starting with a slash are keys too, so a
+// protocol-relative "//host/path" value is never sent to the clients as it is.
+func IsAbsoluteURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
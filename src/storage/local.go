@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type localStorage struct {
+	directory string
+	baseURL   string
+}
+
+// ErrInvalidPresignedUpload is returned for direct uploads to the local storage with a missing, expired or tampered
+// presigned URL
+var ErrInvalidPresignedUpload = errors.New("the presigned upload URL is invalid or expired")
+
+// presignSecret signs the direct upload URLs of the local storage. It is regenerated on every start, which only
+// invalidates the short-lived URLs issued before the restart.
+var presignSecret = func() []byte {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return secret
+}()
+
+// servedContentTypes are the content types of the files the local storage serves to the clients, by the extension of
+// their key. Only the processed image variants have one of these extensions.
+var servedContentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".webp": "image/webp",
+}
+
+// unservedPrefixes are the keys of the direct and resumable uploads, which are stored before they are validated and
+// are never served
+var unservedPrefixes = []string{"pending-uploads/", "tus-uploads/"}
+
+// UseLocal keeps the files in a directory on the local disk and serves them from the server itself, so the server can
+// run without AWS credentials. The base URL is where the directory is served, e.g. http://localhost:8080/uploads.
+// Defaults to the uploads directory served on /uploads.
+func UseLocal(directory, baseURL string) {
+	if directory == "" {
+		directory = "uploads"
+	}
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	backend = &localStorage{directory: directory, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// LocalRoute gets the route and the directory the local storage files should be served from. Returns false when
+// another storage backend is active.
+func LocalRoute() (route, directory string, isLocal bool) {
+	local, isLocal := backend.(*localStorage)
+	if !isLocal {
+		return
+	}
+
+	parsedURL, err := url.Parse(local.baseURL)
+	if err != nil || parsedURL.Path == "" {
+		return "/uploads", local.directory, true
+	}
+	return parsedURL.Path, local.directory, true
+}
+
+// Put writes the file to the storage directory, creating the missing directories of the key. The files are served
+// with the cache control of the local route, since the keys of the stored images never get new content. The content
+// type a file is served with is kept in the extension of its key, so it has to match the given one.
+func (storage *localStorage) Put(key string, body io.ReadSeeker, contentType, _ string) error {
+	if servedContentType, isServed := servedContentTypes[path.Ext(key)]; isServed && servedContentType != contentType {
+		return errors.New("the content type does not match the extension of the key - " + key)
+	}
+
+	_, err := storage.writeFile(key, body, -1)
+	return err
+}
+
+// OpenServed opens a file of the local storage that is served to the clients, together with its content type. Only
+// the processed image variants are served, anything else is reported as not existing.
+func OpenServed(key string) (file *os.File, contentType string, err error) {
+	local, isLocal := backend.(*localStorage)
+	if !isLocal {
+		return nil, "", fs.ErrNotExist
+	}
+
+	cleanKey := strings.TrimPrefix(path.Clean("/"+key), "/")
+	contentType, isServed := servedContentTypes[path.Ext(cleanKey)]
+	for _, prefix := range unservedPrefixes {
+		if strings.HasPrefix(cleanKey, prefix) {
+			isServed = false
+		}
+	}
+	if !isServed {
+		return nil, "", fs.ErrNotExist
+	}
+
+	filePath, err := local.filePath(cleanKey)
+	if err != nil {
+		return nil, "", fs.ErrNotExist
+	}
+
+	file, err = os.Open(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		_ = file.Close()
+		return nil, "", fs.ErrNotExist
+	}
+	return file, contentType, nil
+}
+
+// Get opens the file from the storage directory
+func (storage *localStorage) Get(key string) (io.ReadCloser, error) {
+	filePath, err := storage.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(filePath)
+}
+
+// Delete removes the file from the storage directory. Missing files are not an error, same as with s3.
+func (storage *localStorage) Delete(key string) error {
+	filePath, err := storage.filePath(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// PresignPut builds an URL to the server itself, signed with the content type, size and expiry of the upload
+func (storage *localStorage) PresignPut(key, contentType string, size int64, expiry time.Duration) (string, error) {
+	if _, err := storage.filePath(key); err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("contentType", contentType)
+	query.Set("size", strconv.FormatInt(size, 10))
+	query.Set("expires", strconv.FormatInt(time.Now().Add(expiry).Unix(), 10))
+	query.Set("signature", signPresignedPut(key, query))
+
+	return storage.PublicURL(key) + "?" + query.Encode(), nil
+}
+
+// PutPresigned stores a file uploaded directly to the local storage after verifying the presigned URL it was uploaded
+// with. The content type and size of the upload have to match the signed ones.
+func PutPresigned(key, contentType string, query url.Values, body io.Reader) error {
+	local, isLocal := backend.(*localStorage)
+	if !isLocal {
+		return ErrInvalidPresignedUpload
+	}
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires || contentType != query.Get("contentType") ||
+		!hmac.Equal([]byte(query.Get("signature")), []byte(signPresignedPut(key, query))) {
+		return ErrInvalidPresignedUpload
+	}
+
+	size, err := strconv.ParseInt(query.Get("size"), 10, 64)
+	if err != nil {
+		return ErrInvalidPresignedUpload
+	}
+
+	_, err = local.writeFile(key, body, size)
+	if errors.Is(err, errSizeMismatch) {
+		return ErrInvalidPresignedUpload
+	}
+	return err
+}
+
+var errSizeMismatch = errors.New("the written file does not have the expected size")
+
+// writeFile streams the body to a temporary file next to the target and moves it in place once it is complete, so a
+// broken off upload never leaves a partial file under the key. A non-negative size has to match the body exactly.
+func (storage *localStorage) writeFile(key string, body io.Reader, size int64) (written int64, err error) {
+	filePath, err := storage.filePath(key)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	if size >= 0 {
+		body = io.LimitReader(body, size+1)
+	}
+	written, err = io.Copy(file, body)
+	if err == nil {
+		err = file.Chmod(0o644)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size >= 0 && written != size {
+		err = errSizeMismatch
+	}
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(file.Name(), filePath)
+	return
+}
+
+func signPresignedPut(key string, query url.Values) string {
+	mac := hmac.New(sha256.New, presignSecret)
+	mac.Write([]byte(strings.Join([]string{key, query.Get("contentType"), query.Get("size"), query.Get("expires")}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// List walks the storage directory. A missing directory means nothing was stored yet.
+func (storage *localStorage) List() (objects []StoredObject, err error) {
+	err = filepath.WalkDir(storage.directory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		key, err := filepath.Rel(storage.directory, filePath)
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, StoredObject{Key: filepath.ToSlash(key), Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return
+}
+
+// PublicURL builds the URL the file is served from by the server
+func (storage *localStorage) PublicURL(key string) string {
+	return storage.baseURL + "/" + strings.TrimLeft(key, "/")
+}
+
+// filePath maps the key to a path inside the storage directory and rejects keys that point outside of it
+func (storage *localStorage) filePath(key string) (string, error) {
+	cleanKey := path.Clean("/" + key)
+	if cleanKey == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid storage key - " + key)
+	}
+	return filepath.Join(storage.directory, filepath.FromSlash(cleanKey)), nil
+}
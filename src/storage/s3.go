@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+type s3Storage struct {
+	s3BucketName string
+	s3BucketKey  string
+	s3BucketURL  string
+	ACL          string
+	client       *s3.S3
+}
+
+// UseS3 creates a single s3 session that is used as the storage backend across the application
+func UseS3(bucketName, bucketKey, bucketURL, s3Region, accessKey, secretKey, ACL string) {
+	s3Session := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials(
+			accessKey,
+			secretKey,
+			"",
+		),
+		Region: aws.String(s3Region),
+	}))
+
+	backend = &s3Storage{
+		s3BucketName: bucketName,
+		s3BucketKey:  bucketKey,
+		s3BucketURL:  bucketURL,
+		ACL:          ACL,
+		client:       s3.New(s3Session),
+	}
+}
+
+// Put uploads a file to the s3 bucket with the passed file name (file key), content type and cache control
+func (storage *s3Storage) Put(key string, body io.ReadSeeker, contentType, cacheControl string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(storage.s3BucketName),
+		Key:         aws.String(storage.s3BucketKey + "/" + key),
+		ACL:         aws.String(storage.ACL),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	}
+	if cacheControl != "" {
+		input.CacheControl = aws.String(cacheControl)
+	}
+
+	_, err := storage.client.PutObjectWithContext(ctx, input)
+
+	if err != nil {
+		if uploadError, ok := err.(awserr.Error); ok && uploadError.Code() == request.CanceledErrorCode {
+			return errors.New("upload canceled due to a timeout")
+		}
+		return fmt.Errorf("failed to upload the object to s3 - %s", err.Error())
+	}
+
+	return nil
+}
+
+// Get downloads a file from the s3 bucket with the passed file name (file key)
+func (storage *s3Storage) Get(key string) (io.ReadCloser, error) {
+	response, err := storage.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(storage.s3BucketName),
+		Key:    aws.String(storage.s3BucketKey + "/" + key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download object from s3 - %s", err.Error())
+	}
+	return response.Body, nil
+}
+
+// Delete deletes a file from the s3 bucket with the passed file name (file key)
+func (storage *s3Storage) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	_, err := storage.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(storage.s3BucketName),
+		Key:    aws.String(storage.s3BucketKey + "/" + key),
+	})
+
+	if err != nil {
+		if deleteError, ok := err.(awserr.Error); ok && deleteError.Code() == request.CanceledErrorCode {
+			return errors.New("delete canceled due to a timeout")
+		}
+		return fmt.Errorf("failed to delete the object from s3 - %s", err.Error())
+	}
+
+	return nil
+}
+
+// List enumerates the objects under the bucket key. The returned keys are relative to the bucket key.
+func (storage *s3Storage) List() (objects []StoredObject, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	prefix := storage.s3BucketKey + "/"
+	err = storage.client.ListObjectsV2PagesWithContext(
+		ctx,
+		&s3.ListObjectsV2Input{
+			Bucket: aws.String(storage.s3BucketName),
+			Prefix: aws.String(prefix),
+		},
+		func(page *s3.ListObjectsV2Output, _ bool) bool {
+			for _, object := range page.Contents {
+				objects = append(objects, StoredObject{
+					Key:          strings.TrimPrefix(aws.StringValue(object.Key), prefix),
+					Size:         aws.Int64Value(object.Size),
+					LastModified: aws.TimeValue(object.LastModified),
+				})
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the objects in s3 - %s", err.Error())
+	}
+	return
+}
+
+// PresignPut signs the content type and the content length of the upload, so s3 rejects files that do not match them.
+// The uploaded object is private until it is processed.
+func (storage *s3Storage) PresignPut(key, contentType string, size int64, expiry time.Duration) (string, error) {
+	putRequest, _ := storage.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        aws.String(storage.s3BucketName),
+		Key:           aws.String(storage.s3BucketKey + "/" + key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	})
+	return putRequest.Presign(expiry)
+}
+
+// PublicURL retrieves the full s3 bucket URL of the file
+func (storage *s3Storage) PublicURL(key string) string {
+	return storage.s3BucketURL + "/" + strings.TrimLeft(key, "/")
+}
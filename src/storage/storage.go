@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Storage is where the uploaded files are kept. Files are addressed by their key and served to the clients from their
+// public URL.
+type Storage interface {
+	Put(key string, body io.ReadSeeker, contentType, cacheControl string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	PublicURL(key string) string
+}
+
+// Presigner is implemented by the storage backends the clients can upload files to directly, without the files going
+// through the server
+type Presigner interface {
+	PresignPut(key, contentType string, size int64, expiry time.Duration) (string, error)
+}
+
+// Lister is implemented by the storage backends that can enumerate the files they keep
+type Lister interface {
+	List() ([]StoredObject, error)
+}
+
+// StoredObject describes a file kept in the storage
+type StoredObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// ImmutableCacheControl lets the clients cache a file forever. Used for files whose content never changes under their
+// key.
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+var backend Storage
+
+// Put stores the file under the given key with the active storage backend. The cache control is sent to the clients
+// with the file, an empty one leaves the caching to the defaults of the backend.
+func Put(key string, body io.ReadSeeker, contentType, cacheControl string) error {
+	return backend.Put(key, body, contentType, cacheControl)
+}
+
+// Get opens the file with the given key from the active storage backend. The caller closes it.
+func Get(key string) (io.ReadCloser, error) {
+	return backend.Get(key)
+}
+
+// Delete removes the file with the given key from the active storage backend
+func Delete(key string) error {
+	return backend.Delete(key)
+}
+
+// PublicURL builds the URL the file with the given key is served to the clients from
+func PublicURL(key string) string {
+	if publicBaseURL != "" {
+		return publicBaseURL + "/" + strings.TrimLeft(key, "/")
+	}
+	return backend.PublicURL(key)
+}
+
+// PresignPut issues a short-lived URL the client uploads the file with the given key to with a PUT request. The upload
+// is rejected unless its content type and size match the given ones exactly.
+func PresignPut(key, contentType string, size int64, expiry time.Duration) (string, error) {
+	presigner, isPresigner := backend.(Presigner)
+	if !isPresigner {
+		return "", errors.New("the storage backend does not support direct uploads")
+	}
+	return presigner.PresignPut(key, contentType, size, expiry)
+}
+
+// List enumerates all files kept in the active storage backend
+func List() ([]StoredObject, error) {
+	lister, isLister := backend.(Lister)
+	if !isLister {
+		return nil, errors.New("the storage backend does not support listing files")
+	}
+	return lister.List()
+}